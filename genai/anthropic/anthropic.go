@@ -17,7 +17,6 @@ package anthropic
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -26,6 +25,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/achetronic/adk-utils-go/genai/internal/jsonschema"
+	"github.com/achetronic/adk-utils-go/genai/internal/media"
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 	"google.golang.org/adk/model"
@@ -35,9 +36,20 @@ import (
 var _ model.LLM = &Model{}
 
 var (
-	ErrNoContentInResponse = errors.New("no content in Anthropic response")
+	ErrNoContentInResponse    = errors.New("no content in Anthropic response")
+	ErrInvalidSchema          = jsonschema.ErrInvalidSchema
+	ErrUnsupportedPart        = errors.New("content part not supported by Anthropic")
+	ErrCountTokensUnsupported = errors.New("token counting not supported by this backend")
+	ErrBatchUnsupported       = errors.New("message batches not supported by this backend")
+	ErrBatchRequestFailed     = errors.New("batch request failed")
+	ErrUnknownTool            = errors.New("allowed function name not declared as a tool")
+	ErrThinkingBudget         = errors.New("thinking budget must be below max output tokens")
 )
 
+// defaultMaxTokens is sent when the request sets no MaxOutputTokens, as
+// Anthropic requires max_tokens.
+const defaultMaxTokens = 4096
+
 // anthropicToolIDPattern matches valid Anthropic tool_use IDs: ^[a-zA-Z0-9_-]+$
 var anthropicToolIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
@@ -45,6 +57,24 @@ var anthropicToolIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 type Model struct {
 	client    *anthropic.Client
 	modelName string
+
+	// disableParallelToolUse limits the model to one tool_use per turn.
+	disableParallelToolUse bool
+	// streamToolCalls reports tool_use progress as toolcall.Delta metadata
+	// on partial responses while streaming.
+	streamToolCalls bool
+	// promptCaching places cache breakpoints on requests when set.
+	promptCaching *CacheConfig
+	// structuredOutput selects how JSON responses are requested.
+	structuredOutput StructuredOutputMode
+	// citations enables citations on document blocks.
+	citations bool
+	// normalizationHook receives the changes made to the message history.
+	normalizationHook NormalizationHook
+	// bedrock is set when requests go to Amazon Bedrock.
+	bedrock bool
+	// vertex is set when requests go to Google Vertex AI.
+	vertex bool
 }
 
 // Config holds configuration for creating a new Model.
@@ -55,24 +85,82 @@ type Config struct {
 	BaseURL string
 	// ModelName is the model to use (e.g., "claude-sonnet-4-5-20250929").
 	ModelName string
+	// DisableParallelToolUse sets disable_parallel_tool_use on requests with
+	// tools, so the model uses at most one tool per turn.
+	DisableParallelToolUse bool
+	// DisableSDKRetries turns off the retries the Anthropic SDK makes on its
+	// own (2 by default) for rate limits, overloads and server errors. Set it
+	// when retries are handled elsewhere, e.g. by middleware.Retry.
+	DisableSDKRetries bool
+	// StreamToolCalls yields partial responses as soon as a tool_use block
+	// starts and as its input JSON arrives, as a toolcall.Delta in
+	// CustomMetadata under toolcall.DeltaKey. The FunctionCall parts only
+	// come with the final response.
+	StreamToolCalls bool
+	// PromptCaching enables automatic prompt cache breakpoints on the system
+	// prompt, the tool definitions and the most recent turns. Nil disables it.
+	PromptCaching *CacheConfig
+	// StructuredOutput selects how ResponseSchema and JSON mode are honored:
+	// native output_format or a forced synthetic tool. Defaults to
+	// StructuredOutputAuto.
+	StructuredOutput StructuredOutputMode
+	// Citations enables citations on the document blocks built from PDF and
+	// text parts. Cited ranges are returned in LLMResponse.CitationMetadata.
+	Citations bool
+	// NormalizationHook, if set, is called for every change made to the message
+	// history to satisfy the API: merged messages, dropped empty text, dropped
+	// orphaned tool_use/tool_result blocks and hoisted system contents.
+	NormalizationHook NormalizationHook
+	// Bedrock sends requests to Amazon Bedrock instead of the Anthropic API,
+	// signed with AWS SigV4. APIKey and BaseURL are ignored when set.
+	Bedrock *BedrockConfig
+	// Vertex sends requests to Google Vertex AI instead of the Anthropic API,
+	// authenticated with OAuth tokens. APIKey and BaseURL are ignored when set.
+	Vertex *VertexConfig
 }
 
-// New creates an Anthropic client from config (API key, base URL, model name).
+// New creates an Anthropic client from config (API key, base URL or cloud backend, model name).
 func New(cfg Config) *Model {
 	opts := []option.RequestOption{}
 
-	if cfg.APIKey != "" {
-		opts = append(opts, option.WithAPIKey(cfg.APIKey))
+	switch {
+	case cfg.Bedrock != nil:
+		opts = append(opts, cfg.Bedrock.requestOptions()...)
+	case cfg.Vertex != nil:
+		opts = append(opts, cfg.Vertex.requestOptions()...)
+	default:
+		if cfg.APIKey != "" {
+			opts = append(opts, option.WithAPIKey(cfg.APIKey))
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+		}
 	}
-	if cfg.BaseURL != "" {
-		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+
+	if cfg.DisableSDKRetries {
+		opts = append(opts, option.WithMaxRetries(0))
 	}
 
 	client := anthropic.NewClient(opts...)
 
+	// Native structured outputs are an Anthropic API beta, so cloud backends
+	// use the synthetic tool unless told otherwise
+	structuredOutput := cfg.StructuredOutput
+	if structuredOutput == StructuredOutputAuto && (cfg.Bedrock != nil || cfg.Vertex != nil) {
+		structuredOutput = StructuredOutputTool
+	}
+
 	return &Model{
-		client:    &client,
-		modelName: cfg.ModelName,
+		client:                 &client,
+		modelName:              cfg.ModelName,
+		disableParallelToolUse: cfg.DisableParallelToolUse,
+		streamToolCalls:        cfg.StreamToolCalls,
+		promptCaching:          cfg.PromptCaching,
+		structuredOutput:       structuredOutput,
+		citations:              cfg.Citations,
+		normalizationHook:      cfg.NormalizationHook,
+		bedrock:                cfg.Bedrock != nil,
+		vertex:                 cfg.Vertex != nil,
 	}
 }
 
@@ -98,9 +186,9 @@ func (m *Model) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*
 			return
 		}
 
-		resp, err := m.client.Messages.New(ctx, params)
+		resp, err := m.client.Messages.New(ctx, params, requestOptions(params)...)
 		if err != nil {
-			yield(nil, err)
+			yield(nil, classifyError(err))
 			return
 		}
 
@@ -123,9 +211,14 @@ func (m *Model) generateStream(ctx context.Context, req *model.LLMRequest) iter.
 			return
 		}
 
-		stream := m.client.Messages.NewStreaming(ctx, params)
+		stream := m.client.Messages.NewStreaming(ctx, params, requestOptions(params)...)
 
 		message := anthropic.Message{}
+		toolUses := newToolUseStream()
+		// Index of the synthetic structured output tool_use block, if any
+		outputIndex := int64(-1)
+		// The final response only keeps the tool's JSON, so other text is not streamed
+		structuredOutput := usesStructuredOutputTool(params)
 
 		for stream.Next() {
 			event := stream.Current()
@@ -134,28 +227,52 @@ func (m *Model) generateStream(ctx context.Context, req *model.LLMRequest) iter.
 				return
 			}
 
-			// Yield partial text content
+			// Yield partial text, thinking and structured output content
 			switch eventVariant := event.AsAny().(type) {
+			case anthropic.ContentBlockStartEvent:
+				if eventVariant.ContentBlock.Type == "tool_use" && eventVariant.ContentBlock.Name == structuredOutputToolName {
+					outputIndex = eventVariant.Index
+				}
 			case anthropic.ContentBlockDeltaEvent:
+				var part *genai.Part
 				switch deltaVariant := eventVariant.Delta.AsAny().(type) {
 				case anthropic.TextDelta:
-					if deltaVariant.Text != "" {
-						part := &genai.Part{Text: deltaVariant.Text}
-						llmResp := &model.LLMResponse{
-							Content:      &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
-							Partial:      true,
-							TurnComplete: false,
-						}
-						if !yield(llmResp, nil) {
-							return
-						}
+					if deltaVariant.Text != "" && !structuredOutput {
+						part = &genai.Part{Text: deltaVariant.Text}
+					}
+				case anthropic.ThinkingDelta:
+					if deltaVariant.Thinking != "" {
+						part = &genai.Part{Text: deltaVariant.Thinking, Thought: true}
+					}
+				case anthropic.InputJSONDelta:
+					// Structured output arrives as tool input, streamed as text
+					if eventVariant.Index == outputIndex && deltaVariant.PartialJSON != "" {
+						part = &genai.Part{Text: deltaVariant.PartialJSON}
+					}
+				}
+				if part != nil {
+					llmResp := &model.LLMResponse{
+						Content:      &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
+						Partial:      true,
+						TurnComplete: false,
+					}
+					if !yield(llmResp, nil) {
+						return
+					}
+				}
+			}
+
+			if m.streamToolCalls {
+				if llmResp := toolUses.update(event); llmResp != nil {
+					if !yield(llmResp, nil) {
+						return
 					}
 				}
 			}
 		}
 
 		if err := stream.Err(); err != nil {
-			yield(nil, err)
+			yield(nil, classifyError(err))
 			return
 		}
 
@@ -175,7 +292,7 @@ func (m *Model) generateStream(ctx context.Context, req *model.LLMRequest) iter.
 // buildMessageParams converts an LLMRequest into Anthropic's API format (system prompt, messages, tools, config).
 func (m *Model) buildMessageParams(req *model.LLMRequest) (anthropic.MessageNewParams, error) {
 	// Default max tokens (required by Anthropic API)
-	maxTokens := int64(4096)
+	maxTokens := int64(defaultMaxTokens)
 	if req.Config != nil && req.Config.MaxOutputTokens > 0 {
 		maxTokens = int64(req.Config.MaxOutputTokens)
 	}
@@ -195,9 +312,16 @@ func (m *Model) buildMessageParams(req *model.LLMRequest) (anthropic.MessageNewP
 		}
 	}
 
+	// System-role contents are not messages in Anthropic
+	normalizer := messageNormalizer{hook: m.normalizationHook}
+	contents, systemTexts := normalizer.hoistSystemContents(req.Contents)
+	for _, text := range systemTexts {
+		params.System = append(params.System, anthropic.TextBlockParam{Text: text})
+	}
+
 	// Convert content messages
 	messages := []anthropic.MessageParam{}
-	for _, content := range req.Contents {
+	for _, content := range contents {
 		msg, err := m.convertContentToMessage(content)
 		if err != nil {
 			return anthropic.MessageNewParams{}, err
@@ -207,9 +331,9 @@ func (m *Model) buildMessageParams(req *model.LLMRequest) (anthropic.MessageNewP
 		}
 	}
 
-	// Repair message history to comply with Anthropic's requirements
-	// (each tool_use must have a corresponding tool_result immediately after)
-	messages = repairMessageHistory(messages)
+	// Normalize message history to comply with Anthropic's requirements
+	// (alternating roles, each tool_use with its tool_result immediately after)
+	messages = normalizer.normalize(messages)
 
 	params.Messages = messages
 
@@ -225,6 +349,13 @@ func (m *Model) buildMessageParams(req *model.LLMRequest) (anthropic.MessageNewP
 			params.StopSequences = req.Config.StopSequences
 		}
 
+		// Extended thinking
+		if req.Config.ThinkingConfig != nil {
+			if err := applyThinkingConfig(&params, req.Config.ThinkingConfig, req.Config.MaxOutputTokens); err != nil {
+				return anthropic.MessageNewParams{}, err
+			}
+		}
+
 		// Convert tools
 		if len(req.Config.Tools) > 0 {
 			tools, err := m.convertTools(req.Config.Tools)
@@ -233,38 +364,63 @@ func (m *Model) buildMessageParams(req *model.LLMRequest) (anthropic.MessageNewP
 			}
 			params.Tools = tools
 		}
+
+		// Tool choice only applies when tools are sent
+		if len(params.Tools) > 0 && req.Config.ToolConfig != nil && req.Config.ToolConfig.FunctionCallingConfig != nil {
+			thinking := params.Thinking.OfEnabled != nil
+			choice, tools, err := convertToolChoice(req.Config.ToolConfig.FunctionCallingConfig, params.Tools, thinking)
+			if err != nil {
+				return anthropic.MessageNewParams{}, err
+			}
+			params.ToolChoice, params.Tools = choice, tools
+		}
+
+		// Structured output
+		if err := m.applyStructuredOutput(&params, req.Config); err != nil {
+			return anthropic.MessageNewParams{}, err
+		}
+	}
+
+	if m.disableParallelToolUse && len(params.Tools) > 0 {
+		disableParallelToolUse(&params.ToolChoice)
+	}
+
+	if m.promptCaching != nil {
+		applyCacheControl(&params, m.promptCaching)
 	}
 
 	return params, nil
 }
 
-// convertContentToMessage transforms a genai.Content (text, images, tool calls/results) into an Anthropic message.
+// convertContentToMessage transforms a genai.Content (text, images, documents, tool calls/results) into an Anthropic message.
 func (m *Model) convertContentToMessage(content *genai.Content) (*anthropic.MessageParam, error) {
 	role := convertRoleToAnthropic(content.Role)
 
 	var blocks []anthropic.ContentBlockParamUnion
 
 	for _, part := range content.Parts {
+		// Thoughts are only sent back as signed thinking blocks
+		if part.Thought {
+			if block, ok := convertThoughtToBlock(part); ok && role == anthropic.MessageParamRoleAssistant {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+
 		if part.Text != "" {
 			blocks = append(blocks, anthropic.NewTextBlock(part.Text))
 		}
 
-		if part.InlineData != nil {
-			mediaType := part.InlineData.MIMEType
-			switch mediaType {
-			case "image/jpg", "image/jpeg", "image/png", "image/gif", "image/webp":
-				base64Data := base64.StdEncoding.EncodeToString(part.InlineData.Data)
-				blocks = append(blocks, anthropic.ContentBlockParamUnion{
-					OfImage: &anthropic.ImageBlockParam{
-						Source: anthropic.ImageBlockParamSourceUnion{
-							OfBase64: &anthropic.Base64ImageSourceParam{
-								MediaType: anthropic.Base64ImageSourceMediaType(mediaType),
-								Data:      base64Data,
-							},
-						},
-					},
-				})
+		if media.IsPart(part) {
+			// Anthropic only accepts images and documents from the user
+			if role != anthropic.MessageParamRoleUser {
+				return nil, fmt.Errorf("%w: media in %s messages", ErrUnsupportedPart, role)
+			}
+			block, err := convertMediaPart(part, m.citations)
+			if err != nil {
+				return nil, err
 			}
+			blocks = append(blocks, block)
 		}
 
 		if part.FunctionCall != nil {
@@ -278,11 +434,11 @@ func (m *Model) convertContentToMessage(content *genai.Content) (*anthropic.Mess
 		}
 
 		if part.FunctionResponse != nil {
-			responseJSON, err := json.Marshal(part.FunctionResponse.Response)
+			block, err := m.convertFunctionResponse(part.FunctionResponse)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal function response: %w", err)
+				return nil, err
 			}
-			blocks = append(blocks, anthropic.NewToolResultBlock(sanitizeToolID(part.FunctionResponse.ID), string(responseJSON), false))
+			blocks = append(blocks, block)
 		}
 	}
 
@@ -301,11 +457,22 @@ func (m *Model) convertResponse(resp *anthropic.Message) (*model.LLMResponse, er
 	}
 
 	// Convert content blocks
+	var output *genai.Part
+	var citations citationCollector
 	for _, block := range resp.Content {
 		switch variant := block.AsAny().(type) {
 		case anthropic.TextBlock:
 			content.Parts = append(content.Parts, &genai.Part{Text: variant.Text})
+			citations.add(variant)
+		case anthropic.ThinkingBlock:
+			content.Parts = append(content.Parts, convertThinkingBlock(variant))
+		case anthropic.RedactedThinkingBlock:
+			content.Parts = append(content.Parts, convertRedactedThinkingBlock(variant))
 		case anthropic.ToolUseBlock:
+			if variant.Name == structuredOutputToolName {
+				output = &genai.Part{Text: string(variant.Input)}
+				continue
+			}
 			content.Parts = append(content.Parts, &genai.Part{
 				FunctionCall: &genai.FunctionCall{
 					ID:   variant.ID,
@@ -315,23 +482,37 @@ func (m *Model) convertResponse(resp *anthropic.Message) (*model.LLMResponse, er
 			})
 		}
 	}
+	if output != nil {
+		content.Parts = withStructuredOutput(content.Parts, output)
+	}
 
-	// Convert usage metadata
-	var usageMetadata *genai.GenerateContentResponseUsageMetadata
-	if resp.Usage.InputTokens > 0 || resp.Usage.OutputTokens > 0 {
-		usageMetadata = &genai.GenerateContentResponseUsageMetadata{
-			PromptTokenCount:     int32(resp.Usage.InputTokens),
-			CandidatesTokenCount: int32(resp.Usage.OutputTokens),
-			TotalTokenCount:      int32(resp.Usage.InputTokens + resp.Usage.OutputTokens),
-		}
+	llmResp := &model.LLMResponse{
+		Content:          content,
+		UsageMetadata:    convertUsage(resp.Usage),
+		FinishReason:     convertStopReason(resp.StopReason),
+		CitationMetadata: citations.metadata(),
+		TurnComplete:     true,
 	}
+	if resp.Usage.CacheCreationInputTokens > 0 {
+		llmResp.CustomMetadata = map[string]any{CacheCreationTokensKey: int(resp.Usage.CacheCreationInputTokens)}
+	}
+
+	return llmResp, nil
+}
 
-	return &model.LLMResponse{
-		Content:       content,
-		UsageMetadata: usageMetadata,
-		FinishReason:  convertStopReason(resp.StopReason),
-		TurnComplete:  true,
-	}, nil
+// convertUsage maps Anthropic usage to genai usage metadata. Anthropic's
+// input_tokens excludes cached tokens, so the prompt count adds cache reads and writes.
+func convertUsage(usage anthropic.Usage) *genai.GenerateContentResponseUsageMetadata {
+	promptTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	if promptTokens == 0 && usage.OutputTokens == 0 {
+		return nil
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        int32(promptTokens),
+		CachedContentTokenCount: int32(usage.CacheReadInputTokens),
+		CandidatesTokenCount:    int32(usage.OutputTokens),
+		TotalTokenCount:         int32(promptTokens + usage.OutputTokens),
+	}
 }
 
 // convertTools transforms genai tool definitions into Anthropic's tool format (name, description, JSON schema).
@@ -349,18 +530,9 @@ func (m *Model) convertTools(genaiTools []*genai.Tool) ([]anthropic.ToolUnionPar
 				params = funcDecl.Parameters
 			}
 
-			var inputSchema anthropic.ToolInputSchemaParam
-			// Type is required by Anthropic API, must be "object"
-			inputSchema.Type = "object"
-			if params != nil {
-				if m, ok := params.(map[string]any); ok {
-					if props, ok := m["properties"]; ok {
-						inputSchema.Properties = props
-					}
-					if req, ok := m["required"].([]string); ok {
-						inputSchema.Required = req
-					}
-				}
+			inputSchema, err := convertInputSchema(params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameters for tool %q: %w", funcDecl.Name, err)
 			}
 
 			tools = append(tools, anthropic.ToolUnionParam{
@@ -376,6 +548,82 @@ func (m *Model) convertTools(genaiTools []*genai.Tool) ([]anthropic.ToolUnionPar
 	return tools, nil
 }
 
+// convertToolChoice maps genai function calling modes to Anthropic's tool_choice
+// (AUTO/VALIDATED -> auto, ANY -> any or tool, NONE -> none). Anthropic has no
+// allowed-tools list, so several allowed names restrict the tools sent instead.
+// Allowed names must match declared tools. Thinking does not allow forcing
+// tool use, so ANY falls back to auto over the allowed tools then.
+func convertToolChoice(cfg *genai.FunctionCallingConfig, tools []anthropic.ToolUnionParam, thinking bool) (anthropic.ToolChoiceUnionParam, []anthropic.ToolUnionParam, error) {
+	names := cfg.AllowedFunctionNames
+
+	switch cfg.Mode {
+	case genai.FunctionCallingConfigModeNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}, tools, nil
+	case genai.FunctionCallingConfigModeAny:
+		filtered, err := filterTools(tools, names)
+		if err != nil {
+			return anthropic.ToolChoiceUnionParam{}, nil, err
+		}
+		if thinking {
+			return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}, filtered, nil
+		}
+		if len(names) == 1 {
+			return anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: names[0]}}, tools, nil
+		}
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}, filtered, nil
+	case genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeValidated:
+		filtered, err := filterTools(tools, names)
+		if err != nil {
+			return anthropic.ToolChoiceUnionParam{}, nil, err
+		}
+		return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}, filtered, nil
+	default:
+		return anthropic.ToolChoiceUnionParam{}, tools, nil
+	}
+}
+
+// filterTools keeps only the tools whose names are listed. An empty list keeps
+// all tools. Returns ErrUnknownTool if a listed name is not a declared tool.
+func filterTools(tools []anthropic.ToolUnionParam, names []string) ([]anthropic.ToolUnionParam, error) {
+	if len(names) == 0 {
+		return tools, nil
+	}
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowed[name] = true
+	}
+
+	var filtered []anthropic.ToolUnionParam
+	for _, tool := range tools {
+		if tool.OfTool != nil && allowed[tool.OfTool.Name] {
+			filtered = append(filtered, tool)
+			delete(allowed, tool.OfTool.Name)
+		}
+	}
+	for _, name := range names {
+		if allowed[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+	}
+	return filtered, nil
+}
+
+// disableParallelToolUse sets disable_parallel_tool_use on the tool choice,
+// defaulting to auto when no choice was made. "none" has no such option.
+func disableParallelToolUse(choice *anthropic.ToolChoiceUnionParam) {
+	switch {
+	case choice.OfAuto != nil:
+		choice.OfAuto.DisableParallelToolUse = anthropic.Bool(true)
+	case choice.OfAny != nil:
+		choice.OfAny.DisableParallelToolUse = anthropic.Bool(true)
+	case choice.OfTool != nil:
+		choice.OfTool.DisableParallelToolUse = anthropic.Bool(true)
+	case choice.OfNone != nil:
+	default:
+		choice.OfAuto = &anthropic.ToolChoiceAutoParam{DisableParallelToolUse: anthropic.Bool(true)}
+	}
+}
+
 // convertRoleToAnthropic maps "user"/"model" to Anthropic's role enum (user/assistant).
 func convertRoleToAnthropic(role string) anthropic.MessageParamRole {
 	switch role {
@@ -388,7 +636,7 @@ func convertRoleToAnthropic(role string) anthropic.MessageParamRole {
 	}
 }
 
-// convertStopReason maps Anthropic's stop reasons (end_turn, max_tokens, tool_use) to genai.FinishReason.
+// convertStopReason maps Anthropic's stop reasons (end_turn, max_tokens, tool_use, refusal) to genai.FinishReason.
 func convertStopReason(reason anthropic.StopReason) genai.FinishReason {
 	switch reason {
 	case anthropic.StopReasonEndTurn:
@@ -399,6 +647,8 @@ func convertStopReason(reason anthropic.StopReason) genai.FinishReason {
 		return genai.FinishReasonStop
 	case anthropic.StopReasonToolUse:
 		return genai.FinishReasonStop
+	case anthropic.StopReasonRefusal:
+		return genai.FinishReasonSafety
 	default:
 		return genai.FinishReasonUnspecified
 	}
@@ -479,94 +729,3 @@ func sanitizeToolID(id string) string {
 	hash := sha256.Sum256([]byte(id))
 	return "toolu_" + hex.EncodeToString(hash[:16])
 }
-
-// repairMessageHistory removes orphaned tool_use blocks (those without a matching tool_result in the next message).
-func repairMessageHistory(messages []anthropic.MessageParam) []anthropic.MessageParam {
-	if len(messages) == 0 {
-		return messages
-	}
-
-	result := make([]anthropic.MessageParam, 0, len(messages))
-
-	for i := 0; i < len(messages); i++ {
-		msg := messages[i]
-
-		// Check if this assistant message has tool_use blocks
-		if msg.Role == anthropic.MessageParamRoleAssistant {
-			toolUseIDs := extractToolUseIDs(msg)
-
-			if len(toolUseIDs) > 0 {
-				// Check if next message is a user message with matching tool_results
-				if i+1 < len(messages) && messages[i+1].Role == anthropic.MessageParamRoleUser {
-					toolResultIDs := extractToolResultIDs(messages[i+1])
-
-					// Find which tool_use IDs have matching tool_results
-					matchedIDs := make(map[string]bool)
-					for _, id := range toolResultIDs {
-						matchedIDs[id] = true
-					}
-
-					// Filter out unmatched tool_use blocks from this message
-					filteredMsg := filterToolUse(msg, matchedIDs)
-					if hasContent(filteredMsg) {
-						result = append(result, filteredMsg)
-					}
-					continue
-				} else {
-					// No following user message with tool_results - remove all tool_use blocks
-					filteredMsg := filterToolUse(msg, nil)
-					if hasContent(filteredMsg) {
-						result = append(result, filteredMsg)
-					}
-					continue
-				}
-			}
-		}
-
-		result = append(result, msg)
-	}
-
-	return result
-}
-
-// extractToolUseIDs returns all tool_use IDs from an assistant message.
-func extractToolUseIDs(msg anthropic.MessageParam) []string {
-	var ids []string
-	for _, block := range msg.Content {
-		if block.OfToolUse != nil {
-			ids = append(ids, block.OfToolUse.ID)
-		}
-	}
-	return ids
-}
-
-// extractToolResultIDs returns all tool_result IDs from a user message.
-func extractToolResultIDs(msg anthropic.MessageParam) []string {
-	var ids []string
-	for _, block := range msg.Content {
-		if block.OfToolResult != nil {
-			ids = append(ids, block.OfToolResult.ToolUseID)
-		}
-	}
-	return ids
-}
-
-// filterToolUse keeps tool_use blocks whose IDs are in allowedIDs. If allowedIDs is nil, removes all tool_use.
-func filterToolUse(msg anthropic.MessageParam, allowedIDs map[string]bool) anthropic.MessageParam {
-	var filteredBlocks []anthropic.ContentBlockParamUnion
-	for _, block := range msg.Content {
-		if block.OfToolUse != nil {
-			if allowedIDs != nil && allowedIDs[block.OfToolUse.ID] {
-				filteredBlocks = append(filteredBlocks, block)
-			}
-			continue
-		}
-		filteredBlocks = append(filteredBlocks, block)
-	}
-	return anthropic.MessageParam{Role: msg.Role, Content: filteredBlocks}
-}
-
-// hasContent returns true if the message has at least one content block.
-func hasContent(msg anthropic.MessageParam) bool {
-	return len(msg.Content) > 0
-}
@@ -0,0 +1,213 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// newTestServer starts an httptest server that is closed when the test ends.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeJSON writes a JSON response body.
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeSSE writes Anthropic stream events, using each event's type as the SSE event name.
+func writeSSE(t *testing.T, w http.ResponseWriter, events ...map[string]any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			t.Errorf("Failed to marshal event: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+	}
+}
+
+// message builds a minimal Messages API response with the given content blocks.
+func message(stopReason string, content ...any) map[string]any {
+	return map[string]any{
+		"id":          "msg_1",
+		"type":        "message",
+		"role":        "assistant",
+		"model":       "claude-test",
+		"content":     content,
+		"stop_reason": stopReason,
+		"usage":       map[string]any{"input_tokens": 10, "output_tokens": 20},
+	}
+}
+
+// collect drains a GenerateContent iterator, failing the test on error.
+func collect(t *testing.T, m *Model, req *model.LLMRequest, stream bool) []*model.LLMResponse {
+	t.Helper()
+	var responses []*model.LLMResponse
+	for resp, err := range m.GenerateContent(context.Background(), req, stream) {
+		if err != nil {
+			t.Fatalf("GenerateContent failed: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func userRequest(text string) *model.LLMRequest {
+	return &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+		{Name: "get_weather"},
+		{Name: "get_time"},
+		{Name: "get_date"},
+	}}}
+
+	tests := []struct {
+		name      string
+		fcc       *genai.FunctionCallingConfig
+		want      string
+		wantTools int
+	}{
+		{"auto", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}, `{"disable_parallel_tool_use":true,"type":"auto"}`, 3},
+		{"none", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}, `{"type":"none"}`, 3},
+		{"any", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}, `{"disable_parallel_tool_use":true,"type":"any"}`, 3},
+		{"any single name", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{"get_time"},
+		}, `{"name":"get_time","disable_parallel_tool_use":true,"type":"tool"}`, 3},
+		{"any several names", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{"get_time", "get_date"},
+		}, `{"disable_parallel_tool_use":true,"type":"any"}`, 2},
+		{"unspecified", &genai.FunctionCallingConfig{}, `{"disable_parallel_tool_use":true,"type":"auto"}`, 3},
+	}
+
+	m := New(Config{ModelName: "claude-test", DisableParallelToolUse: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := m.buildMessageParams(&model.LLMRequest{
+				Contents: userRequest("hi").Contents,
+				Config: &genai.GenerateContentConfig{
+					Tools:      tools,
+					ToolConfig: &genai.ToolConfig{FunctionCallingConfig: tt.fcc},
+				},
+			})
+			if err != nil {
+				t.Fatalf("buildMessageParams failed: %v", err)
+			}
+
+			got, err := json.Marshal(params.ToolChoice)
+			if err != nil {
+				t.Fatalf("Failed to marshal tool choice: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected tool_choice %s, got %s", tt.want, got)
+			}
+			if len(params.Tools) != tt.wantTools {
+				t.Errorf("Expected %d tools, got %d", tt.wantTools, len(params.Tools))
+			}
+		})
+	}
+}
+
+func TestToolChoiceThinking(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}, {Name: "get_time"}}}}
+
+	tests := []struct {
+		names     []string
+		wantTools int
+	}{
+		{nil, 2},
+		{[]string{"get_time"}, 1},
+	}
+
+	m := New(Config{ModelName: "claude-test"})
+	for _, tt := range tests {
+		params, err := m.buildMessageParams(&model.LLMRequest{
+			Contents: userRequest("hi").Contents,
+			Config: &genai.GenerateContentConfig{
+				Tools:          tools,
+				ThinkingConfig: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelLow},
+				ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode:                 genai.FunctionCallingConfigModeAny,
+					AllowedFunctionNames: tt.names,
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("buildMessageParams failed: %v", err)
+		}
+		if params.ToolChoice.OfAuto == nil {
+			t.Errorf("Names %v: expected tool_choice auto with thinking, got %+v", tt.names, params.ToolChoice)
+		}
+		if len(params.Tools) != tt.wantTools {
+			t.Errorf("Names %v: expected %d tools, got %d", tt.names, tt.wantTools, len(params.Tools))
+		}
+	}
+}
+
+func TestToolChoiceUnknownTool(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}}
+
+	m := New(Config{ModelName: "claude-test"})
+	for _, mode := range []genai.FunctionCallingConfigMode{genai.FunctionCallingConfigModeAny, genai.FunctionCallingConfigModeAuto} {
+		_, err := m.buildMessageParams(&model.LLMRequest{
+			Contents: userRequest("hi").Contents,
+			Config: &genai.GenerateContentConfig{
+				Tools: tools,
+				ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode:                 mode,
+					AllowedFunctionNames: []string{"get_time"},
+				}},
+			},
+		})
+		if !errors.Is(err, ErrUnknownTool) || !strings.Contains(err.Error(), "get_time") {
+			t.Errorf("Mode %s: expected ErrUnknownTool naming get_time, got %v", mode, err)
+		}
+	}
+}
+
+func TestRefusal(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("refusal", map[string]any{"type": "text", "text": "I can't help with that."}))
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	responses := collect(t, m, userRequest("hi"), false)
+	if len(responses) != 1 || responses[0].FinishReason != genai.FinishReasonSafety {
+		t.Errorf("Expected a SAFETY finish reason for a refusal, got %v", responses)
+	}
+}
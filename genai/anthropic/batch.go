@@ -0,0 +1,223 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"google.golang.org/adk/model"
+)
+
+const (
+	defaultBatchPollInterval    = 10 * time.Second
+	defaultBatchMaxPollInterval = 5 * time.Minute
+)
+
+// batchCustomIDPattern matches valid Message Batch custom IDs.
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// BatchConfig holds configuration for creating a new BatchSubmitter.
+type BatchConfig struct {
+	// PollInterval is the wait before the first status check of a batch.
+	// It doubles after every check. Defaults to 10 seconds.
+	PollInterval time.Duration
+	// MaxPollInterval caps the wait between status checks. Defaults to 5 minutes.
+	MaxPollInterval time.Duration
+}
+
+// BatchResult is the outcome of one request of a Message Batch: either a
+// response converted as GenerateContent would return it, or an error.
+type BatchResult struct {
+	Response *model.LLMResponse
+	Err      error
+}
+
+// BatchSubmitter runs many requests through the Message Batches API, which
+// processes them asynchronously at a lower price than the Messages API. It
+// suits offline jobs where results can take minutes to hours.
+type BatchSubmitter struct {
+	model           *Model
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+}
+
+// NewBatchSubmitter creates a BatchSubmitter that builds requests with the
+// settings of the given Model (model name, caching, structured output...).
+func NewBatchSubmitter(m *Model, cfg BatchConfig) *BatchSubmitter {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultBatchPollInterval
+	}
+	maxPollInterval := cfg.MaxPollInterval
+	if maxPollInterval <= 0 {
+		maxPollInterval = defaultBatchMaxPollInterval
+	}
+	maxPollInterval = max(maxPollInterval, pollInterval)
+
+	return &BatchSubmitter{
+		model:           m,
+		pollInterval:    pollInterval,
+		maxPollInterval: maxPollInterval,
+	}
+}
+
+// Run submits the requests as one Message Batch, waits for it to end and
+// returns the results keyed by custom ID. See Submit and Wait.
+func (b *BatchSubmitter) Run(ctx context.Context, requests map[string]*model.LLMRequest) (map[string]BatchResult, error) {
+	batchID, err := b.Submit(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	return b.Wait(ctx, batchID)
+}
+
+// Submit converts the requests, keyed by custom ID, and creates a Message
+// Batch with them. Custom IDs must match ^[a-zA-Z0-9_-]{1,64}$. It returns
+// the batch ID, to be passed to Wait, possibly from another process.
+// Bedrock and Vertex AI do not expose this API and return ErrBatchUnsupported.
+func (b *BatchSubmitter) Submit(ctx context.Context, requests map[string]*model.LLMRequest) (string, error) {
+	if b.model.bedrock || b.model.vertex {
+		return "", ErrBatchUnsupported
+	}
+	if len(requests) == 0 {
+		return "", fmt.Errorf("failed to create message batch: no requests")
+	}
+
+	var opts []option.RequestOption
+	batchRequests := make([]anthropic.MessageBatchNewParamsRequest, 0, len(requests))
+	for _, customID := range slices.Sorted(maps.Keys(requests)) {
+		if !batchCustomIDPattern.MatchString(customID) {
+			return "", fmt.Errorf("invalid custom ID %q: must match %s", customID, batchCustomIDPattern)
+		}
+		params, err := b.model.buildMessageParams(requests[customID])
+		if err != nil {
+			return "", fmt.Errorf("failed to build request %q: %w", customID, err)
+		}
+		// The beta header applies to the whole batch
+		if opts == nil {
+			opts = requestOptions(params)
+		}
+		batchRequests = append(batchRequests, anthropic.MessageBatchNewParamsRequest{
+			CustomID: customID,
+			Params:   batchRequestParams(params),
+		})
+	}
+
+	batch, err := b.model.client.Messages.Batches.New(ctx, anthropic.MessageBatchNewParams{Requests: batchRequests}, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create message batch: %w", classifyError(err))
+	}
+	return batch.ID, nil
+}
+
+// Wait polls the batch with exponential backoff until it ends, then returns
+// its results keyed by custom ID. Requests that errored, were canceled or
+// expired have a BatchResult.Err wrapping ErrBatchRequestFailed, classified
+// into llmerror when the error type is known.
+func (b *BatchSubmitter) Wait(ctx context.Context, batchID string) (map[string]BatchResult, error) {
+	if b.model.bedrock || b.model.vertex {
+		return nil, ErrBatchUnsupported
+	}
+
+	interval := b.pollInterval
+	for {
+		batch, err := b.model.client.Messages.Batches.Get(ctx, batchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message batch %s: %w", batchID, classifyError(err))
+		}
+		if batch.ProcessingStatus == anthropic.MessageBatchProcessingStatusEnded {
+			break
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		interval = min(interval*2, b.maxPollInterval)
+	}
+
+	return b.results(ctx, batchID)
+}
+
+// results downloads the results of an ended batch and converts each one.
+func (b *BatchSubmitter) results(ctx context.Context, batchID string) (map[string]BatchResult, error) {
+	stream := b.model.client.Messages.Batches.ResultsStreaming(ctx, batchID)
+	defer stream.Close()
+
+	results := make(map[string]BatchResult)
+	for stream.Next() {
+		item := stream.Current()
+		results[item.CustomID] = b.convertBatchResult(item.Result)
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get message batch %s results: %w", batchID, classifyError(err))
+	}
+	return results, nil
+}
+
+// convertBatchResult converts the result of one batch request.
+func (b *BatchSubmitter) convertBatchResult(result anthropic.MessageBatchResultUnion) BatchResult {
+	switch result.Type {
+	case "succeeded":
+		llmResp, err := b.model.convertResponse(&result.Message)
+		return BatchResult{Response: llmResp, Err: err}
+	case "errored":
+		apiErr := result.Error.Error
+		err := fmt.Errorf("%w: %s: %s", ErrBatchRequestFailed, apiErr.Type, apiErr.Message)
+		var body errorBody
+		body.Error.Type, body.Error.Message = apiErr.Type, apiErr.Message
+		if kind := errorKind(body); kind != nil {
+			err = &llmerror.Error{Kind: kind, Provider: providerName, RequestID: result.Error.RequestID, Err: err}
+		}
+		return BatchResult{Err: err}
+	default:
+		// canceled or expired
+		return BatchResult{Err: fmt.Errorf("%w: %s", ErrBatchRequestFailed, result.Type)}
+	}
+}
+
+// batchRequestParams copies a Messages request into the params of a batch request.
+func batchRequestParams(params anthropic.MessageNewParams) anthropic.MessageBatchNewParamsRequestParams {
+	batchParams := anthropic.MessageBatchNewParamsRequestParams{
+		MaxTokens:     params.MaxTokens,
+		Messages:      params.Messages,
+		Model:         params.Model,
+		Temperature:   params.Temperature,
+		TopK:          params.TopK,
+		TopP:          params.TopP,
+		Metadata:      params.Metadata,
+		ServiceTier:   string(params.ServiceTier),
+		StopSequences: params.StopSequences,
+		System:        params.System,
+		Thinking:      params.Thinking,
+		ToolChoice:    params.ToolChoice,
+		Tools:         params.Tools,
+	}
+	if extraFields := params.ExtraFields(); len(extraFields) > 0 {
+		batchParams.SetExtraFields(extraFields)
+	}
+	return batchParams
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+)
+
+// messageBatch builds a minimal Message Batches API object.
+func messageBatch(status string) map[string]any {
+	return map[string]any{
+		"id":                "msgbatch_1",
+		"type":              "message_batch",
+		"processing_status": status,
+		"request_counts":    map[string]any{"processing": 0, "succeeded": 1, "errored": 1, "canceled": 0, "expired": 1},
+	}
+}
+
+func TestBatchSubmitter(t *testing.T) {
+	var reqBody map[string]any
+	polls := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/messages/batches":
+			json.NewDecoder(r.Body).Decode(&reqBody)
+			writeJSON(w, messageBatch("in_progress"))
+		case "GET /v1/messages/batches/msgbatch_1":
+			polls++
+			if polls < 3 {
+				writeJSON(w, messageBatch("in_progress"))
+				return
+			}
+			writeJSON(w, messageBatch("ended"))
+		case "GET /v1/messages/batches/msgbatch_1/results":
+			w.Header().Set("Content-Type", "application/x-jsonl")
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]any{"custom_id": "summary-1", "result": map[string]any{
+				"type": "succeeded", "message": message("end_turn", map[string]any{"type": "text", "text": "A summary."}),
+			}})
+			enc.Encode(map[string]any{"custom_id": "summary-2", "result": map[string]any{
+				"type": "errored", "error": map[string]any{"type": "error", "error": map[string]any{"type": "invalid_request_error", "message": "bad request"}},
+			}})
+			enc.Encode(map[string]any{"custom_id": "summary-3", "result": map[string]any{"type": "expired"}})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	batch := NewBatchSubmitter(m, BatchConfig{PollInterval: time.Millisecond, MaxPollInterval: 2 * time.Millisecond})
+
+	results, err := batch.Run(context.Background(), map[string]*model.LLMRequest{
+		"summary-1": userRequest("Summarize session 1"),
+		"summary-2": userRequest("Summarize session 2"),
+		"summary-3": userRequest("Summarize session 3"),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	requests, _ := reqBody["requests"].([]any)
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 batch requests, got %v", reqBody)
+	}
+	first := requests[0].(map[string]any)
+	params := first["params"].(map[string]any)
+	if first["custom_id"] != "summary-1" || params["model"] != "claude-test" || params["max_tokens"] == nil || len(params["messages"].([]any)) != 1 {
+		t.Errorf("Expected converted Messages params, got %v", first)
+	}
+	if polls != 3 {
+		t.Errorf("Expected 3 status checks, got %d", polls)
+	}
+
+	if got := results["summary-1"]; got.Err != nil || got.Response.Content.Parts[0].Text != "A summary." {
+		t.Errorf("Expected a converted response for summary-1, got %+v", got)
+	}
+	for _, id := range []string{"summary-2", "summary-3"} {
+		if err := results[id].Err; !errors.Is(err, ErrBatchRequestFailed) {
+			t.Errorf("Expected ErrBatchRequestFailed for %s, got %v", id, err)
+		}
+	}
+}
+
+func TestBatchSubmitterErrors(t *testing.T) {
+	m := New(Config{APIKey: "test", ModelName: "claude-test"})
+	batch := NewBatchSubmitter(m, BatchConfig{})
+
+	if _, err := batch.Submit(context.Background(), map[string]*model.LLMRequest{"bad id": userRequest("Hi")}); err == nil {
+		t.Error("Expected an error for an invalid custom ID")
+	}
+	if _, err := batch.Submit(context.Background(), nil); err == nil {
+		t.Error("Expected an error for an empty batch")
+	}
+
+	bedrock := New(Config{ModelName: "claude-test", Bedrock: &BedrockConfig{Region: "us-east-1"}})
+	requests := map[string]*model.LLMRequest{"summary-1": userRequest("Hi")}
+	if _, err := NewBatchSubmitter(bedrock, BatchConfig{}).Submit(context.Background(), requests); !errors.Is(err, ErrBatchUnsupported) {
+		t.Errorf("Expected ErrBatchUnsupported on Bedrock, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// BedrockConfig configures access to Claude through Amazon Bedrock.
+// Requests are signed with AWS SigV4 and ModelName is a Bedrock model ID or
+// inference profile (e.g., "us.anthropic.claude-sonnet-4-5-20250929-v1:0").
+type BedrockConfig struct {
+	// Region is the AWS region of the Bedrock runtime endpoint (e.g., "us-east-1").
+	Region string
+	// Credentials provides the AWS credentials used to sign requests. If nil,
+	// the default AWS credential chain (env vars, shared config, IAM roles) is
+	// loaded on the first request.
+	Credentials aws.CredentialsProvider
+	// BaseURL overrides the endpoint (optional, e.g. for VPC endpoints or a
+	// local stand-in). Defaults to https://bedrock-runtime.{Region}.amazonaws.com.
+	BaseURL string
+}
+
+// requestOptions returns the client options that route and sign requests
+// for Bedrock. Anthropic API credentials from the environment are dropped.
+func (c BedrockConfig) requestOptions() []option.RequestOption {
+	credentials := c.Credentials
+	if credentials == nil {
+		credentials = aws.NewCredentialsCache(defaultAWSCredentials{region: c.Region})
+	}
+
+	opts := []option.RequestOption{
+		option.WithHeaderDel("X-Api-Key"),
+		option.WithHeaderDel("Authorization"),
+		bedrock.WithConfig(aws.Config{Region: c.Region, Credentials: credentials}),
+	}
+	if c.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(c.BaseURL))
+	}
+	return opts
+}
+
+// defaultAWSCredentials loads the default AWS credential chain when first
+// used, so missing credentials fail requests instead of New.
+type defaultAWSCredentials struct {
+	region string
+}
+
+// Retrieve implements aws.CredentialsProvider.
+func (p defaultAWSCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(p.region))
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Credentials == nil {
+		return aws.Credentials{}, fmt.Errorf("no AWS credentials found")
+	}
+	return cfg.Credentials.Retrieve(ctx)
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestBedrock(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-key")
+
+	var gotReq *http.Request
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("end_turn", map[string]any{"type": "text", "text": "Hi"}))
+	})
+
+	credentials := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+	})
+	m := New(Config{
+		ModelName: "us.anthropic.claude-sonnet-4-5-20250929-v1:0",
+		Bedrock:   &BedrockConfig{Region: "us-east-1", Credentials: credentials, BaseURL: server.URL},
+	})
+	responses := collect(t, m, userRequest("hello"), false)
+
+	if gotReq.URL.Path != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1:0/invoke" {
+		t.Errorf("Expected Bedrock invoke path, got %s", gotReq.URL.Path)
+	}
+	if auth := gotReq.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/us-east-1/bedrock/") {
+		t.Errorf("Expected SigV4 signature, got %q", auth)
+	}
+	if gotReq.Header.Get("X-Api-Key") != "" {
+		t.Errorf("Expected Anthropic API key not to be sent to Bedrock")
+	}
+	if reqBody["anthropic_version"] == nil || reqBody["model"] != nil {
+		t.Errorf("Expected Bedrock request body, got %v", reqBody)
+	}
+	if responses[0].Content.Parts[0].Text != "Hi" {
+		t.Errorf("Unexpected response: %+v", responses[0].Content)
+	}
+	if m.structuredOutput != StructuredOutputTool {
+		t.Errorf("Expected the synthetic tool for structured output on Bedrock, got %q", m.structuredOutput)
+	}
+}
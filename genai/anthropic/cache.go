@@ -0,0 +1,105 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// CacheCreationTokensKey is the CustomMetadata key holding the number of
+// input tokens written to the prompt cache (cache_creation_input_tokens).
+// Tokens read from the cache are reported in UsageMetadata.CachedContentTokenCount.
+const CacheCreationTokensKey = "cache_creation_input_tokens"
+
+// CacheTTL is the lifetime of prompt cache entries.
+type CacheTTL string
+
+const (
+	// CacheTTL5m keeps cache entries for 5 minutes (Anthropic's default).
+	CacheTTL5m CacheTTL = "5m"
+	// CacheTTL1h keeps cache entries for 1 hour, at a higher write cost.
+	CacheTTL1h CacheTTL = "1h"
+)
+
+// maxMessageBreakpoints is the number of breakpoints left for messages once
+// the system prompt and tools have theirs (Anthropic allows 4 per request).
+const maxMessageBreakpoints = 2
+
+// CacheConfig places ephemeral cache_control breakpoints on every request:
+// after the tool definitions, after the system prompt and on the most recent
+// conversation turns, so the shared prefix is read from the prompt cache.
+type CacheConfig struct {
+	// TTL of the cache entries. Defaults to CacheTTL5m.
+	TTL CacheTTL
+	// MessageBreakpoints is how many of the most recent messages get a
+	// breakpoint (0 to 2). Defaults to 2, so the previous turn's prefix is
+	// read from the cache while the current one is written.
+	MessageBreakpoints *int
+}
+
+// cacheControl returns the cache_control value for the configured TTL.
+func (c *CacheConfig) cacheControl() anthropic.CacheControlEphemeralParam {
+	cacheControl := anthropic.NewCacheControlEphemeralParam()
+	if c.TTL != "" {
+		cacheControl.TTL = anthropic.CacheControlEphemeralTTL(c.TTL)
+	}
+	return cacheControl
+}
+
+// messageBreakpoints returns the number of message breakpoints to place.
+func (c *CacheConfig) messageBreakpoints() int {
+	if c.MessageBreakpoints == nil {
+		return maxMessageBreakpoints
+	}
+	return min(max(*c.MessageBreakpoints, 0), maxMessageBreakpoints)
+}
+
+// applyCacheControl sets the cache breakpoints on the request params.
+func applyCacheControl(params *anthropic.MessageNewParams, cfg *CacheConfig) {
+	cacheControl := cfg.cacheControl()
+
+	if n := len(params.Tools); n > 0 {
+		if cc := params.Tools[n-1].GetCacheControl(); cc != nil {
+			*cc = cacheControl
+		}
+	}
+
+	if n := len(params.System); n > 0 {
+		params.System[n-1].CacheControl = cacheControl
+	}
+
+	remaining := cfg.messageBreakpoints()
+	for i := len(params.Messages) - 1; i >= 0 && remaining > 0; i-- {
+		if setMessageCacheControl(&params.Messages[i], cacheControl) {
+			remaining--
+		}
+	}
+}
+
+// setMessageCacheControl places a breakpoint on the last block of a message
+// that accepts one (thinking blocks do not). Returns false if none does.
+func setMessageCacheControl(msg *anthropic.MessageParam, cacheControl anthropic.CacheControlEphemeralParam) bool {
+	for i := len(msg.Content) - 1; i >= 0; i-- {
+		block := msg.Content[i]
+		if block.OfThinking != nil || block.OfRedactedThinking != nil {
+			continue
+		}
+		if cc := block.GetCacheControl(); cc != nil {
+			*cc = cacheControl
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestPromptCaching(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reqBody = nil
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		resp := message("end_turn", map[string]any{"type": "text", "text": "Hi"})
+		resp["usage"] = map[string]any{
+			"input_tokens":                10,
+			"cache_creation_input_tokens": 200,
+			"cache_read_input_tokens":     1000,
+			"output_tokens":               20,
+		}
+		writeJSON(w, resp)
+	})
+
+	m := New(Config{
+		BaseURL:       server.URL,
+		APIKey:        "test",
+		ModelName:     "claude-test",
+		PromptCaching: &CacheConfig{TTL: CacheTTL1h},
+	})
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			genai.NewContentFromText("first", genai.RoleUser),
+			genai.NewContentFromText("answer", genai.RoleModel),
+			genai.NewContentFromText("second", genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Long system prompt", genai.RoleUser),
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "get_weather"},
+				{Name: "get_time"},
+			}}},
+		},
+	}
+	resp := collect(t, m, req, false)[0]
+
+	hasBreakpoint := func(block any) bool {
+		cc, ok := block.(map[string]any)["cache_control"].(map[string]any)
+		return ok && cc["type"] == "ephemeral" && cc["ttl"] == "1h"
+	}
+
+	tools := reqBody["tools"].([]any)
+	if hasBreakpoint(tools[0]) || !hasBreakpoint(tools[1]) {
+		t.Errorf("Expected a breakpoint on the last tool only, got %v", tools)
+	}
+	if system := reqBody["system"].([]any); !hasBreakpoint(system[0]) {
+		t.Errorf("Expected a breakpoint on the system prompt, got %v", system)
+	}
+	messages := reqBody["messages"].([]any)
+	for i, want := range []bool{false, true, true} {
+		block := messages[i].(map[string]any)["content"].([]any)[0]
+		if hasBreakpoint(block) != want {
+			t.Errorf("Message %d: expected breakpoint=%v, got %v", i, want, block)
+		}
+	}
+
+	usage := resp.UsageMetadata
+	if usage.PromptTokenCount != 1210 || usage.CachedContentTokenCount != 1000 || usage.TotalTokenCount != 1230 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if resp.CustomMetadata[CacheCreationTokensKey] != 200 {
+		t.Errorf("Expected cache creation tokens in CustomMetadata, got %v", resp.CustomMetadata)
+	}
+
+	// Without a caching policy no breakpoints are sent
+	m = New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	collect(t, m, req, false)
+	if system := reqBody["system"].([]any); system[0].(map[string]any)["cache_control"] != nil {
+		t.Errorf("Expected no cache_control, got %v", system)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// providerName identifies this client in llmerror.Error.
+const providerName = "anthropic"
+
+// streamErrorPrefix starts the message of errors received as stream events.
+const streamErrorPrefix = "received error while streaming: "
+
+// errorKinds maps Anthropic error types to the llmerror taxonomy.
+var errorKinds = map[string]error{
+	"rate_limit_error":      llmerror.ErrRateLimited,
+	"overloaded_error":      llmerror.ErrOverloaded,
+	"api_error":             llmerror.ErrServer,
+	"timeout_error":         llmerror.ErrTimeout,
+	"authentication_error":  llmerror.ErrAuthentication,
+	"permission_error":      llmerror.ErrAuthentication,
+	"invalid_request_error": llmerror.ErrInvalidRequest,
+	"request_too_large":     llmerror.ErrInvalidRequest,
+	"not_found_error":       llmerror.ErrInvalidRequest,
+}
+
+// errorBody is the body of an Anthropic error response or error event.
+type errorBody struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// classifyError wraps SDK errors into an llmerror.Error, from the HTTP
+// status and the error type of the body. Other errors are returned as-is.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var llmErr *llmerror.Error
+	if errors.As(err, &llmErr) {
+		return err
+	}
+
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		classified := llmerror.New(providerName, apiErr.Response, apiErr.RequestID, err)
+		if classified == nil {
+			return err
+		}
+		var body errorBody
+		if json.Unmarshal([]byte(apiErr.RawJSON()), &body) == nil {
+			if kind := errorKind(body); kind != nil {
+				classified.Kind = kind
+			}
+		}
+		return classified
+	}
+
+	// Errors received mid-stream carry the error event as JSON
+	if _, data, ok := strings.Cut(err.Error(), streamErrorPrefix); ok {
+		var body errorBody
+		if json.Unmarshal([]byte(data), &body) == nil {
+			if kind := errorKind(body); kind != nil {
+				return &llmerror.Error{Kind: kind, Provider: providerName, Err: err}
+			}
+		}
+	}
+
+	if llmerror.IsTimeout(err) {
+		return &llmerror.Error{Kind: llmerror.ErrTimeout, Provider: providerName, Err: err}
+	}
+	return err
+}
+
+// errorKind returns the kind of an Anthropic error body, or nil if unknown.
+func errorKind(body errorBody) error {
+	kind := errorKinds[body.Error.Type]
+	if kind == llmerror.ErrInvalidRequest && isContextLengthMessage(body.Error.Message) {
+		return llmerror.ErrContextLengthExceeded
+	}
+	return kind
+}
+
+// isContextLengthMessage reports whether an invalid request error is about
+// the prompt not fitting in the context window.
+func isContextLengthMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "prompt is too long") || strings.Contains(message, "context limit") || strings.Contains(message, "context window")
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		errorType string
+		message   string
+		want      error
+	}{
+		{"overloaded", 529, "overloaded_error", "Overloaded", llmerror.ErrOverloaded},
+		{"rate limited", http.StatusTooManyRequests, "rate_limit_error", "Too many requests", llmerror.ErrRateLimited},
+		{"context length", http.StatusBadRequest, "invalid_request_error", "prompt is too long: 210000 tokens > 200000 maximum", llmerror.ErrContextLengthExceeded},
+		{"invalid request", http.StatusBadRequest, "invalid_request_error", "max_tokens: Field required", llmerror.ErrInvalidRequest},
+		{"authentication", http.StatusUnauthorized, "authentication_error", "invalid x-api-key", llmerror.ErrAuthentication},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Request-Id", "req_1")
+				w.Header().Set("Retry-After-Ms", "1")
+				w.WriteHeader(tt.status)
+				writeJSON(w, map[string]any{"type": "error", "error": map[string]any{"type": tt.errorType, "message": tt.message}})
+			})
+
+			m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test", DisableSDKRetries: true})
+			var err error
+			for _, err = range m.GenerateContent(context.Background(), userRequest("Hi"), false) {
+			}
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			if requests != 1 {
+				t.Errorf("Expected SDK retries to be disabled, got %d requests", requests)
+			}
+			var llmErr *llmerror.Error
+			if !errors.As(err, &llmErr) || llmErr.StatusCode != tt.status || llmErr.RequestID != "req_1" || llmErr.Provider != "anthropic" {
+				t.Errorf("Expected status, request ID and provider, got %+v", llmErr)
+			}
+			var apiErr *anthropic.Error
+			if !errors.As(err, &apiErr) {
+				t.Errorf("Expected the SDK error to be wrapped, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClassifyStreamError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w, map[string]any{"type": "error", "error": map[string]any{"type": "overloaded_error", "message": "Overloaded"}})
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	var err error
+	for _, err = range m.GenerateContent(context.Background(), userRequest("Hi"), true) {
+	}
+	if !errors.Is(err, llmerror.ErrOverloaded) {
+		t.Errorf("Expected ErrOverloaded from a stream error event, got %v", err)
+	}
+}
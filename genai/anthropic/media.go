@@ -0,0 +1,118 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/media"
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/genai"
+)
+
+// convertMediaPart converts an inline or file data part to a content block:
+// images as image blocks, and PDFs and text files as document blocks, with
+// citations enabled when requested. File URIs must be URLs of images or PDFs.
+func convertMediaPart(part *genai.Part, citations bool) (anthropic.ContentBlockParamUnion, error) {
+	if data := part.InlineData; data != nil {
+		switch media.KindOf(data.MIMEType) {
+		case media.KindImage:
+			mediaType := media.BaseType(data.MIMEType)
+			if mediaType == "image/jpg" {
+				mediaType = "image/jpeg"
+			}
+			return anthropic.NewImageBlockBase64(mediaType, base64.StdEncoding.EncodeToString(data.Data)), nil
+		case media.KindPDF:
+			return documentBlock(anthropic.DocumentBlockParamSourceUnion{
+				OfBase64: &anthropic.Base64PDFSourceParam{Data: base64.StdEncoding.EncodeToString(data.Data)},
+			}, data.DisplayName, citations), nil
+		case media.KindText:
+			return documentBlock(anthropic.DocumentBlockParamSourceUnion{
+				OfText: &anthropic.PlainTextSourceParam{Data: string(data.Data)},
+			}, data.DisplayName, citations), nil
+		}
+		return anthropic.ContentBlockParamUnion{}, fmt.Errorf("%w: inline data of type %q", ErrUnsupportedPart, data.MIMEType)
+	}
+
+	data := part.FileData
+	if media.IsHTTPURL(data.FileURI) {
+		mimeType := data.MIMEType
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(path.Ext(data.FileURI))
+		}
+		switch media.KindOf(mimeType) {
+		case media.KindImage:
+			return anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: data.FileURI}), nil
+		case media.KindPDF:
+			return documentBlock(anthropic.DocumentBlockParamSourceUnion{
+				OfURL: &anthropic.URLPDFSourceParam{URL: data.FileURI},
+			}, data.DisplayName, citations), nil
+		}
+	}
+	return anthropic.ContentBlockParamUnion{}, fmt.Errorf("%w: file %q of type %q", ErrUnsupportedPart, data.FileURI, data.MIMEType)
+}
+
+// documentBlock builds a document block with an optional title.
+func documentBlock(source anthropic.DocumentBlockParamSourceUnion, title string, citations bool) anthropic.ContentBlockParamUnion {
+	document := &anthropic.DocumentBlockParam{Source: source}
+	if title != "" {
+		document.Title = anthropic.String(title)
+	}
+	if citations {
+		document.Citations = anthropic.CitationsConfigParam{Enabled: anthropic.Bool(true)}
+	}
+	return anthropic.ContentBlockParamUnion{OfDocument: document}
+}
+
+// citationCollector maps the citations of response text blocks to genai
+// citations, indexed by byte offset into the concatenated response text.
+type citationCollector struct {
+	offset    int
+	citations []*genai.Citation
+}
+
+// add records the citations of a text block and advances past its text.
+func (c *citationCollector) add(block anthropic.TextBlock) {
+	start, end := c.offset, c.offset+len(block.Text)
+	c.offset = end
+
+	for _, citation := range block.Citations {
+		title := citation.DocumentTitle
+		if title == "" {
+			title = citation.Title
+		}
+		uri := citation.URL
+		if uri == "" {
+			uri = citation.Source
+		}
+		c.citations = append(c.citations, &genai.Citation{
+			StartIndex: int32(start),
+			EndIndex:   int32(end),
+			Title:      title,
+			URI:        uri,
+		})
+	}
+}
+
+// metadata returns the collected citations, or nil when there are none.
+func (c *citationCollector) metadata() *genai.CitationMetadata {
+	if len(c.citations) == 0 {
+		return nil
+	}
+	return &genai.CitationMetadata{Citations: c.citations}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestConvertMediaPart(t *testing.T) {
+	tests := []struct {
+		name      string
+		part      *genai.Part
+		citations bool
+		want      string
+	}{
+		{"jpg image", &genai.Part{InlineData: &genai.Blob{MIMEType: "image/jpg", Data: []byte("img")}},
+			false, `{"source":{"data":"aW1n","media_type":"image/jpeg","type":"base64"},"type":"image"}`},
+		{"pdf", &genai.Part{InlineData: &genai.Blob{MIMEType: "application/pdf", Data: []byte("pdf"), DisplayName: "report.pdf"}},
+			true, `{"source":{"data":"cGRm","media_type":"application/pdf","type":"base64"},"title":"report.pdf","citations":{"enabled":true},"type":"document"}`},
+		{"plain text", &genai.Part{InlineData: &genai.Blob{MIMEType: "text/plain; charset=utf-8", Data: []byte("hello")}},
+			false, `{"source":{"data":"hello","media_type":"text/plain","type":"text"},"type":"document"}`},
+		{"pdf url", &genai.Part{FileData: &genai.FileData{FileURI: "https://example.com/doc.pdf"}},
+			false, `{"source":{"url":"https://example.com/doc.pdf","type":"url"},"type":"document"}`},
+		{"image url", &genai.Part{FileData: &genai.FileData{FileURI: "https://example.com/cat.png", MIMEType: "image/png"}},
+			false, `{"source":{"url":"https://example.com/cat.png","type":"url"},"type":"image"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := convertMediaPart(tt.part, tt.citations)
+			if err != nil {
+				t.Fatalf("convertMediaPart failed: %v", err)
+			}
+			got, err := json.Marshal(block)
+			if err != nil {
+				t.Fatalf("Failed to marshal block: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	unsupported := []*genai.Part{
+		{InlineData: &genai.Blob{MIMEType: "audio/wav", Data: []byte("wav")}},
+		{FileData: &genai.FileData{FileURI: "files/abc123", MIMEType: "application/pdf"}},
+		{FileData: &genai.FileData{FileURI: "https://example.com/song.mp3"}},
+	}
+	for _, part := range unsupported {
+		if _, err := convertMediaPart(part, false); !errors.Is(err, ErrUnsupportedPart) {
+			t.Errorf("Expected ErrUnsupportedPart for %+v, got %v", part, err)
+		}
+	}
+
+	// Media is only accepted from the user
+	m := New(Config{ModelName: "claude-test"})
+	_, err := m.convertContentToMessage(&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+		{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("img")}},
+	}})
+	if !errors.Is(err, ErrUnsupportedPart) {
+		t.Errorf("Expected ErrUnsupportedPart for media in model message, got %v", err)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("end_turn",
+			map[string]any{"type": "text", "text": "According to the report, "},
+			map[string]any{"type": "text", "text": "revenue grew 10%.", "citations": []any{map[string]any{
+				"type":              "page_location",
+				"cited_text":        "Revenue grew 10% year over year.",
+				"document_index":    0,
+				"document_title":    "report.pdf",
+				"start_page_number": 2,
+				"end_page_number":   3,
+			}}},
+		))
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test", Citations: true})
+	req := &model.LLMRequest{Contents: []*genai.Content{{Role: genai.RoleUser, Parts: []*genai.Part{
+		{InlineData: &genai.Blob{MIMEType: "application/pdf", Data: []byte("pdf"), DisplayName: "report.pdf"}},
+		{Text: "How did revenue change?"},
+	}}}}
+	responses := collect(t, m, req, false)
+
+	content := reqBody["messages"].([]any)[0].(map[string]any)["content"].([]any)
+	document := content[0].(map[string]any)
+	if document["type"] != "document" || document["citations"].(map[string]any)["enabled"] != true {
+		t.Errorf("Expected document block with citations, got %v", document)
+	}
+
+	metadata := responses[0].CitationMetadata
+	if metadata == nil || len(metadata.Citations) != 1 {
+		t.Fatalf("Expected one citation, got %+v", metadata)
+	}
+	citation := metadata.Citations[0]
+	if citation.StartIndex != 25 || citation.EndIndex != 42 || citation.Title != "report.pdf" {
+		t.Errorf("Unexpected citation: %+v", citation)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/genai"
+)
+
+// NormalizationKind identifies a change made to the message history before
+// sending it to Anthropic.
+type NormalizationKind string
+
+const (
+	// NormalizationHoistSystem moves a system-role content into the system prompt.
+	NormalizationHoistSystem NormalizationKind = "hoist_system"
+	// NormalizationDropEmptyText removes a text block with no visible text.
+	NormalizationDropEmptyText NormalizationKind = "drop_empty_text"
+	// NormalizationMergeMessages merges a message into the previous one with the same role.
+	NormalizationMergeMessages NormalizationKind = "merge_messages"
+	// NormalizationDropToolUse removes a tool_use block with no tool_result in the next message.
+	NormalizationDropToolUse NormalizationKind = "drop_tool_use"
+	// NormalizationDropToolResult removes a tool_result block with no tool_use in the previous message.
+	NormalizationDropToolResult NormalizationKind = "drop_tool_result"
+	// NormalizationDropEmptyMessage removes a message left without content blocks.
+	NormalizationDropEmptyMessage NormalizationKind = "drop_empty_message"
+)
+
+// NormalizationChange describes a single change made to the message history.
+type NormalizationChange struct {
+	// Kind of change.
+	Kind NormalizationKind
+	// Index of the affected message (or of the content, for
+	// NormalizationHoistSystem) in the history at the step making the change.
+	Index int
+	// Role of the affected message.
+	Role string
+	// Detail is a human readable description, e.g. the tool call ID.
+	Detail string
+}
+
+// NormalizationHook receives every change made to the message history. It is
+// useful to debug corrupted histories restored from session storage.
+type NormalizationHook func(change NormalizationChange)
+
+// messageNormalizer makes a message history acceptable to the Anthropic API:
+// no empty text, strictly alternating roles and tool_use/tool_result pairs in
+// consecutive messages.
+type messageNormalizer struct {
+	hook NormalizationHook
+}
+
+// report sends a change to the hook, if any.
+func (n messageNormalizer) report(kind NormalizationKind, index int, role anthropic.MessageParamRole, detail string) {
+	if n.hook != nil {
+		n.hook(NormalizationChange{Kind: kind, Index: index, Role: string(role), Detail: detail})
+	}
+}
+
+// hoistSystemContents removes system-role contents from the history and
+// returns their text, to be appended to the system prompt.
+func (n messageNormalizer) hoistSystemContents(contents []*genai.Content) ([]*genai.Content, []string) {
+	var systemTexts []string
+	result := make([]*genai.Content, 0, len(contents))
+	for i, content := range contents {
+		if content == nil {
+			continue
+		}
+		if content.Role != "system" {
+			result = append(result, content)
+			continue
+		}
+		text := extractTextFromContent(content)
+		if text != "" {
+			systemTexts = append(systemTexts, text)
+		}
+		n.report(NormalizationHoistSystem, i, "system", fmt.Sprintf("%d characters", len(text)))
+	}
+	return result, systemTexts
+}
+
+// normalize applies every repair to the messages.
+func (n messageNormalizer) normalize(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	messages = n.dropEmptyText(messages)
+	messages = n.dropEmptyMessages(messages)
+	messages = n.mergeMessages(messages)
+	messages = n.repairToolUse(messages)
+	messages = n.repairToolResults(messages)
+	// Dropping blocks can leave empty messages and same-role neighbors
+	messages = n.dropEmptyMessages(messages)
+	return n.mergeMessages(messages)
+}
+
+// dropEmptyText removes text blocks that are empty or whitespace only, which
+// the API rejects.
+func (n messageNormalizer) dropEmptyText(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	for i, msg := range messages {
+		var filteredBlocks []anthropic.ContentBlockParamUnion
+		for _, block := range msg.Content {
+			if block.OfText != nil && strings.TrimSpace(block.OfText.Text) == "" {
+				n.report(NormalizationDropEmptyText, i, msg.Role, "")
+				continue
+			}
+			filteredBlocks = append(filteredBlocks, block)
+		}
+		messages[i].Content = filteredBlocks
+	}
+	return messages
+}
+
+// dropEmptyMessages removes messages without content blocks.
+func (n messageNormalizer) dropEmptyMessages(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	result := make([]anthropic.MessageParam, 0, len(messages))
+	for i, msg := range messages {
+		if !hasContent(msg) {
+			n.report(NormalizationDropEmptyMessage, i, msg.Role, "")
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// mergeMessages merges consecutive messages with the same role, as the API
+// requires alternating roles. In user messages, tool_result blocks are moved
+// before other content, and in assistant messages thinking and
+// redacted_thinking blocks are, where the API expects them.
+func (n messageNormalizer) mergeMessages(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	result := make([]anthropic.MessageParam, 0, len(messages))
+	for i, msg := range messages {
+		if len(result) == 0 || result[len(result)-1].Role != msg.Role {
+			result = append(result, msg)
+			continue
+		}
+
+		n.report(NormalizationMergeMessages, i, msg.Role, fmt.Sprintf("merged into message %d", len(result)-1))
+		prev := &result[len(result)-1]
+		content := make([]anthropic.ContentBlockParamUnion, 0, len(prev.Content)+len(msg.Content))
+		content = append(content, prev.Content...)
+		content = append(content, msg.Content...)
+		switch msg.Role {
+		case anthropic.MessageParamRoleUser:
+			content = blocksFirst(content, isToolResult)
+		case anthropic.MessageParamRoleAssistant:
+			content = blocksFirst(content, isThinking)
+		}
+		prev.Content = content
+	}
+	return result
+}
+
+// blocksFirst reorders blocks so those matching first come first, keeping
+// the relative order within both groups.
+func blocksFirst(blocks []anthropic.ContentBlockParamUnion, first func(anthropic.ContentBlockParamUnion) bool) []anthropic.ContentBlockParamUnion {
+	result := make([]anthropic.ContentBlockParamUnion, 0, len(blocks))
+	for _, block := range blocks {
+		if first(block) {
+			result = append(result, block)
+		}
+	}
+	for _, block := range blocks {
+		if !first(block) {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+// isToolResult reports whether a block is a tool_result block.
+func isToolResult(block anthropic.ContentBlockParamUnion) bool {
+	return block.OfToolResult != nil
+}
+
+// isThinking reports whether a block is a thinking or redacted_thinking block.
+func isThinking(block anthropic.ContentBlockParamUnion) bool {
+	return block.OfThinking != nil || block.OfRedactedThinking != nil
+}
+
+// repairToolUse removes orphaned tool_use blocks (those without a matching
+// tool_result in the next message).
+func (n messageNormalizer) repairToolUse(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	for i, msg := range messages {
+		if msg.Role != anthropic.MessageParamRoleAssistant || len(extractToolUseIDs(msg)) == 0 {
+			continue
+		}
+
+		// Tool results must be in the next user message
+		matchedIDs := make(map[string]bool)
+		if i+1 < len(messages) && messages[i+1].Role == anthropic.MessageParamRoleUser {
+			for _, id := range extractToolResultIDs(messages[i+1]) {
+				matchedIDs[id] = true
+			}
+		}
+
+		for _, id := range extractToolUseIDs(msg) {
+			if !matchedIDs[id] {
+				n.report(NormalizationDropToolUse, i, msg.Role, id)
+			}
+		}
+		messages[i] = filterToolUse(msg, matchedIDs)
+	}
+	return messages
+}
+
+// repairToolResults removes orphaned tool_result blocks (those without a
+// matching tool_use in the previous message).
+func (n messageNormalizer) repairToolResults(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	for i, msg := range messages {
+		if msg.Role != anthropic.MessageParamRoleUser || len(extractToolResultIDs(msg)) == 0 {
+			continue
+		}
+
+		matchedIDs := make(map[string]bool)
+		if i > 0 && messages[i-1].Role == anthropic.MessageParamRoleAssistant {
+			for _, id := range extractToolUseIDs(messages[i-1]) {
+				matchedIDs[id] = true
+			}
+		}
+
+		var filteredBlocks []anthropic.ContentBlockParamUnion
+		for _, block := range msg.Content {
+			if block.OfToolResult != nil && !matchedIDs[block.OfToolResult.ToolUseID] {
+				n.report(NormalizationDropToolResult, i, msg.Role, block.OfToolResult.ToolUseID)
+				continue
+			}
+			filteredBlocks = append(filteredBlocks, block)
+		}
+		messages[i].Content = filteredBlocks
+	}
+	return messages
+}
+
+// extractToolUseIDs returns all tool_use IDs from an assistant message.
+func extractToolUseIDs(msg anthropic.MessageParam) []string {
+	var ids []string
+	for _, block := range msg.Content {
+		if block.OfToolUse != nil {
+			ids = append(ids, block.OfToolUse.ID)
+		}
+	}
+	return ids
+}
+
+// extractToolResultIDs returns all tool_result IDs from a user message.
+func extractToolResultIDs(msg anthropic.MessageParam) []string {
+	var ids []string
+	for _, block := range msg.Content {
+		if block.OfToolResult != nil {
+			ids = append(ids, block.OfToolResult.ToolUseID)
+		}
+	}
+	return ids
+}
+
+// filterToolUse keeps tool_use blocks whose IDs are in allowedIDs. If allowedIDs is nil, removes all tool_use.
+func filterToolUse(msg anthropic.MessageParam, allowedIDs map[string]bool) anthropic.MessageParam {
+	var filteredBlocks []anthropic.ContentBlockParamUnion
+	for _, block := range msg.Content {
+		if block.OfToolUse != nil {
+			if allowedIDs != nil && allowedIDs[block.OfToolUse.ID] {
+				filteredBlocks = append(filteredBlocks, block)
+			}
+			continue
+		}
+		filteredBlocks = append(filteredBlocks, block)
+	}
+	return anthropic.MessageParam{Role: msg.Role, Content: filteredBlocks}
+}
+
+// hasContent returns true if the message has at least one content block.
+func hasContent(msg anthropic.MessageParam) bool {
+	return len(msg.Content) > 0
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestNormalizeMessages(t *testing.T) {
+	call := func(id string) *genai.Part {
+		return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: "get_weather", Args: map[string]any{}}}
+	}
+	result := func(id string) *genai.Part {
+		return &genai.Part{FunctionResponse: &genai.FunctionResponse{ID: id, Name: "get_weather", Response: map[string]any{"temp": 20}}}
+	}
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "system", Parts: []*genai.Part{{Text: "Answer in French."}}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "Weather in Paris?"}}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "  "}}},
+			{Role: genai.RoleModel, Parts: []*genai.Part{call("toolu_1"), call("toolu_2")}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "Also Lyon."}}},
+			{Role: genai.RoleUser, Parts: []*genai.Part{result("toolu_1"), result("toolu_9")}},
+			{Role: genai.RoleModel, Parts: []*genai.Part{{Text: "Il fait 20 degrés."}}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("You are a weather bot.", genai.RoleUser),
+		},
+	}
+
+	var changes []NormalizationChange
+	m := New(Config{ModelName: "claude-test", NormalizationHook: func(change NormalizationChange) {
+		changes = append(changes, change)
+	}})
+	params, err := m.buildMessageParams(req)
+	if err != nil {
+		t.Fatalf("buildMessageParams failed: %v", err)
+	}
+
+	got, err := json.Marshal(params.Messages)
+	if err != nil {
+		t.Fatalf("Failed to marshal messages: %v", err)
+	}
+	want := `[{"content":[{"text":"Weather in Paris?","type":"text"}],"role":"user"},` +
+		`{"content":[{"id":"toolu_1","input":{},"name":"get_weather","type":"tool_use"}],"role":"assistant"},` +
+		`{"content":[{"tool_use_id":"toolu_1","content":[{"text":"{\"temp\":20}","type":"text"}],"type":"tool_result"},{"text":"Also Lyon.","type":"text"}],"role":"user"},` +
+		`{"content":[{"text":"Il fait 20 degrés.","type":"text"}],"role":"assistant"}]`
+	if string(got) != want {
+		t.Errorf("Unexpected messages:\nwant %s\ngot  %s", want, got)
+	}
+
+	if len(params.System) != 2 || params.System[1].Text != "Answer in French." {
+		t.Errorf("Expected system content to be hoisted, got %+v", params.System)
+	}
+
+	counts := make(map[NormalizationKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	wantCounts := map[NormalizationKind]int{
+		NormalizationHoistSystem:      1,
+		NormalizationDropEmptyText:    1,
+		NormalizationDropEmptyMessage: 1,
+		NormalizationMergeMessages:    1,
+		NormalizationDropToolUse:      1,
+		NormalizationDropToolResult:   1,
+	}
+	for kind, n := range wantCounts {
+		if counts[kind] != n {
+			t.Errorf("Expected %d %s changes, got %d (%+v)", n, kind, counts[kind], changes)
+		}
+	}
+	for _, change := range changes {
+		if change.Kind == NormalizationDropToolUse && change.Detail != "toolu_2" {
+			t.Errorf("Expected toolu_2 to be dropped, got %+v", change)
+		}
+		if change.Kind == NormalizationDropToolResult && change.Detail != "toolu_9" {
+			t.Errorf("Expected toolu_9 to be dropped, got %+v", change)
+		}
+	}
+}
+
+func TestMergeMessagesThinkingFirst(t *testing.T) {
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("Weather in Paris?")),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("Let me check.")),
+		anthropic.NewAssistantMessage(
+			anthropic.NewRedactedThinkingBlock("redacted"),
+			anthropic.NewThinkingBlock("sig", "The user wants the weather."),
+			anthropic.NewTextBlock("It is sunny."),
+		),
+	}
+
+	merged := messageNormalizer{}.normalize(messages)
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(merged))
+	}
+
+	content := merged[1].Content
+	if len(content) != 4 || content[0].OfRedactedThinking == nil || content[1].OfThinking == nil || content[2].OfText == nil || content[3].OfText == nil {
+		got, _ := json.Marshal(content)
+		t.Fatalf("Expected thinking blocks first, got %s", got)
+	}
+	if text := content[2].OfText.Text; text != "Let me check." {
+		t.Errorf("Expected text order to be kept, got %q", text)
+	}
+}
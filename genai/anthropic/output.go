@@ -0,0 +1,199 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/jsonschema"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"google.golang.org/genai"
+)
+
+// StructuredOutputMode selects how JSON responses (ResponseMIMEType
+// "application/json" and ResponseSchema/ResponseJsonSchema) are requested.
+type StructuredOutputMode string
+
+const (
+	// StructuredOutputAuto uses native structured outputs for models known to
+	// support them when a schema is set, and the synthetic tool otherwise.
+	StructuredOutputAuto StructuredOutputMode = ""
+	// StructuredOutputNative sends the schema as output_format (beta).
+	StructuredOutputNative StructuredOutputMode = "native"
+	// StructuredOutputTool forces a synthetic tool whose input is the schema.
+	StructuredOutputTool StructuredOutputMode = "tool"
+)
+
+const (
+	// structuredOutputBeta is the beta enabling output_format.
+	structuredOutputBeta = "structured-outputs-2025-11-13"
+	// structuredOutputToolName is the synthetic tool used as fallback. Its
+	// tool_use input is returned as the text of the response.
+	structuredOutputToolName = "structured_output"
+	// outputFormatKey is the request field holding the native output format.
+	outputFormatKey = "output_format"
+)
+
+// nativeStructuredOutputModels are the model name prefixes that support output_format.
+var nativeStructuredOutputModels = []string{
+	"claude-sonnet-4-5",
+	"claude-opus-4-1",
+	"claude-opus-4-5",
+	"claude-haiku-4-5",
+}
+
+// supportsNativeStructuredOutput reports whether a model accepts output_format.
+func supportsNativeStructuredOutput(modelName string) bool {
+	for _, prefix := range nativeStructuredOutputModels {
+		if strings.HasPrefix(modelName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStructuredOutput requests a JSON response when the config asks for one,
+// either natively through output_format or with a forced synthetic tool. In
+// both cases the JSON comes back to ADK as a plain text part.
+func (m *Model) applyStructuredOutput(params *anthropic.MessageNewParams, cfg *genai.GenerateContentConfig) error {
+	var schema map[string]any
+	var err error
+
+	switch {
+	case cfg.ResponseJsonSchema != nil:
+		schema, err = jsonschema.ToMap(cfg.ResponseJsonSchema)
+	case cfg.ResponseSchema != nil:
+		schema, err = jsonschema.ToMap(cfg.ResponseSchema)
+	case cfg.ResponseMIMEType == "application/json":
+		// JSON mode without a schema accepts any object
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to convert response schema: %w", err)
+	}
+	if schema != nil {
+		if err := validateSchema(schema); err != nil {
+			return fmt.Errorf("failed to convert response schema: %w", err)
+		}
+		removePropertyOrdering(schema)
+	}
+
+	native := m.structuredOutput == StructuredOutputNative ||
+		(m.structuredOutput == StructuredOutputAuto && schema != nil && supportsNativeStructuredOutput(m.modelName))
+	if native && schema != nil {
+		closeObjectSchemas(schema)
+		// Merge with extra fields set by other options
+		extraFields := maps.Clone(params.ExtraFields())
+		if extraFields == nil {
+			extraFields = make(map[string]any)
+		}
+		extraFields[outputFormatKey] = map[string]any{"type": "json_schema", "schema": schema}
+		params.SetExtraFields(extraFields)
+		return nil
+	}
+
+	return addStructuredOutputTool(params, schema)
+}
+
+// addStructuredOutputTool adds the synthetic output tool and makes the model
+// call it: directly when it is the only tool, or through "any" when the agent
+// has other tools to use first. Thinking does not allow forcing tool use, so
+// the choice is left on auto then. The tool choice of the request narrows the
+// agent's tools: none drops them, and a forced tool is the only one kept.
+func addStructuredOutputTool(params *anthropic.MessageNewParams, schema map[string]any) error {
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+	inputSchema, err := convertInputSchema(schema)
+	if err != nil {
+		return fmt.Errorf("failed to convert response schema: %w", err)
+	}
+
+	switch {
+	case params.ToolChoice.OfNone != nil:
+		params.Tools = nil
+	case params.ToolChoice.OfTool != nil:
+		params.Tools, err = filterTools(params.Tools, []string{params.ToolChoice.OfTool.Name})
+		if err != nil {
+			return err
+		}
+	}
+
+	params.Tools = append(params.Tools, anthropic.ToolUnionParam{
+		OfTool: &anthropic.ToolParam{
+			Name:        structuredOutputToolName,
+			Description: anthropic.String("Respond to the user with the final answer. The input of this tool is the response."),
+			InputSchema: inputSchema,
+		},
+	})
+
+	switch {
+	case params.Thinking.OfEnabled != nil:
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	case len(params.Tools) == 1:
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: structuredOutputToolName}}
+	default:
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	}
+	// A single tool per turn keeps the answer from mixing with other calls
+	disableParallelToolUse(&params.ToolChoice)
+
+	return nil
+}
+
+// closeObjectSchemas sets additionalProperties to false on every object
+// schema that does not set it, as required by output_format.
+func closeObjectSchemas(schema map[string]any) {
+	if jsonschema.HasType(schema, "object") {
+		if _, ok := schema["additionalProperties"]; !ok {
+			schema["additionalProperties"] = false
+		}
+	}
+	jsonschema.ForEachSubschema(schema, closeObjectSchemas)
+}
+
+// requestOptions returns the per-request options needed by the params, such
+// as the beta header for native structured outputs.
+func requestOptions(params anthropic.MessageNewParams) []option.RequestOption {
+	if _, ok := params.ExtraFields()[outputFormatKey]; ok {
+		return []option.RequestOption{option.WithHeaderAdd("anthropic-beta", structuredOutputBeta)}
+	}
+	return nil
+}
+
+// usesStructuredOutputTool reports whether the params ask for the synthetic
+// structured output tool.
+func usesStructuredOutputTool(params anthropic.MessageNewParams) bool {
+	return slices.ContainsFunc(params.Tools, func(tool anthropic.ToolUnionParam) bool {
+		return tool.OfTool != nil && tool.OfTool.Name == structuredOutputToolName
+	})
+}
+
+// withStructuredOutput replaces the text of a response with the synthetic
+// tool's input, so the JSON is the only text ADK parses. Thoughts are kept.
+func withStructuredOutput(parts []*genai.Part, output *genai.Part) []*genai.Part {
+	result := make([]*genai.Part, 0, len(parts)+1)
+	for _, part := range parts {
+		if part.Thought {
+			result = append(result, part)
+		}
+	}
+	return append(result, output)
+}
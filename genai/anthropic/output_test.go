@@ -0,0 +1,242 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// outputRequest asks for a JSON response matching a small object schema.
+func outputRequest() *model.LLMRequest {
+	req := userRequest("What is the capital of France?")
+	req.Config = &genai.GenerateContentConfig{
+		ResponseSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"city": {Type: genai.TypeString},
+			},
+			Required: []string{"city"},
+		},
+	}
+	return req
+}
+
+func TestStructuredOutputNative(t *testing.T) {
+	var reqBody map[string]any
+	var beta string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("Anthropic-Beta")
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("end_turn", map[string]any{"type": "text", "text": `{"city":"Paris"}`}))
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-sonnet-4-5-20250929"})
+	responses := collect(t, m, outputRequest(), false)
+
+	if beta != structuredOutputBeta {
+		t.Errorf("Expected structured outputs beta header, got %q", beta)
+	}
+	format, _ := reqBody["output_format"].(map[string]any)
+	schema, _ := format["schema"].(map[string]any)
+	if format["type"] != "json_schema" || schema["additionalProperties"] != false {
+		t.Errorf("Unexpected output_format: %v", reqBody["output_format"])
+	}
+	if _, ok := reqBody["tools"]; ok {
+		t.Errorf("Expected no synthetic tool, got %v", reqBody["tools"])
+	}
+	if got := responses[0].Content.Parts[0].Text; got != `{"city":"Paris"}` {
+		t.Errorf("Expected JSON text, got %q", got)
+	}
+}
+
+func TestStructuredOutputKeepsExtraFields(t *testing.T) {
+	m := New(Config{ModelName: "claude-sonnet-4-5-20250929"})
+
+	var params anthropic.MessageNewParams
+	params.SetExtraFields(map[string]any{"context_management": map[string]any{"edits": []any{}}})
+	if err := m.applyStructuredOutput(&params, outputRequest().Config); err != nil {
+		t.Fatalf("applyStructuredOutput failed: %v", err)
+	}
+
+	extraFields := params.ExtraFields()
+	if _, ok := extraFields["context_management"]; !ok {
+		t.Errorf("Expected existing extra fields to be kept, got %v", extraFields)
+	}
+	if _, ok := extraFields[outputFormatKey]; !ok {
+		t.Errorf("Expected output_format, got %v", extraFields)
+	}
+}
+
+func TestStructuredOutputTool(t *testing.T) {
+	var reqBody map[string]any
+	var beta string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("Anthropic-Beta")
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("tool_use",
+			map[string]any{"type": "text", "text": "Here is the answer."},
+			map[string]any{"type": "tool_use", "id": "toolu_1", "name": structuredOutputToolName, "input": map[string]any{"city": "Paris"}},
+		))
+	})
+
+	// Older models fall back to the synthetic tool
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-3-5-haiku-20241022"})
+	responses := collect(t, m, outputRequest(), false)
+
+	if beta != "" || reqBody["output_format"] != nil {
+		t.Errorf("Expected no native output format, got beta=%q output_format=%v", beta, reqBody["output_format"])
+	}
+	tools, _ := reqBody["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["name"] != structuredOutputToolName {
+		t.Fatalf("Expected synthetic tool, got %v", reqBody["tools"])
+	}
+	if choice := reqBody["tool_choice"].(map[string]any); choice["type"] != "tool" || choice["name"] != structuredOutputToolName {
+		t.Errorf("Expected forced synthetic tool, got %v", choice)
+	}
+
+	parts := responses[0].Content.Parts
+	if len(parts) != 1 || parts[0].FunctionCall != nil || parts[0].Text != `{"city":"Paris"}` {
+		t.Errorf("Expected a single JSON text part, got %+v", parts)
+	}
+	if responses[0].FinishReason != genai.FinishReasonStop {
+		t.Errorf("Expected STOP, got %v", responses[0].FinishReason)
+	}
+}
+
+func TestStructuredOutputToolWithTools(t *testing.T) {
+	m := New(Config{ModelName: "claude-test", StructuredOutput: StructuredOutputTool})
+
+	req := outputRequest()
+	req.Config.Tools = []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}}
+	params, err := m.buildMessageParams(req)
+	if err != nil {
+		t.Fatalf("buildMessageParams failed: %v", err)
+	}
+	if len(params.Tools) != 2 || params.ToolChoice.OfAny == nil {
+		t.Errorf("Expected tool_choice any with both tools, got %d tools and %+v", len(params.Tools), params.ToolChoice)
+	}
+
+	// JSON mode without a schema accepts any object
+	req = userRequest("hi")
+	req.Config = &genai.GenerateContentConfig{ResponseMIMEType: "application/json"}
+	params, err = m.buildMessageParams(req)
+	if err != nil {
+		t.Fatalf("buildMessageParams failed: %v", err)
+	}
+	if len(params.Tools) != 1 || params.ToolChoice.OfTool == nil {
+		t.Errorf("Expected forced synthetic tool for JSON mode, got %+v", params.ToolChoice)
+	}
+
+	// The synthetic tool input must be an object
+	req = userRequest("hi")
+	req.Config = &genai.GenerateContentConfig{ResponseSchema: &genai.Schema{Type: genai.TypeArray}}
+	if _, err := m.buildMessageParams(req); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Expected ErrInvalidSchema for array schema, got %v", err)
+	}
+}
+
+func TestStructuredOutputToolWithToolChoice(t *testing.T) {
+	m := New(Config{ModelName: "claude-test", StructuredOutput: StructuredOutputTool})
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}, {Name: "get_time"}}}}
+
+	tests := []struct {
+		name      string
+		fcc       *genai.FunctionCallingConfig
+		wantTools []string
+	}{
+		{"none", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}, []string{structuredOutputToolName}},
+		{"any single name", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{"get_time"},
+		}, []string{"get_time", structuredOutputToolName}},
+		{"auto allowed names", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAuto,
+			AllowedFunctionNames: []string{"get_weather"},
+		}, []string{"get_weather", structuredOutputToolName}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := outputRequest()
+			req.Config.Tools = tools
+			req.Config.ToolConfig = &genai.ToolConfig{FunctionCallingConfig: tt.fcc}
+			params, err := m.buildMessageParams(req)
+			if err != nil {
+				t.Fatalf("buildMessageParams failed: %v", err)
+			}
+
+			var names []string
+			for _, tool := range params.Tools {
+				names = append(names, tool.OfTool.Name)
+			}
+			if !slices.Equal(names, tt.wantTools) {
+				t.Errorf("Expected tools %v, got %v", tt.wantTools, names)
+			}
+			if len(names) == 1 && params.ToolChoice.OfTool == nil || len(names) > 1 && params.ToolChoice.OfAny == nil {
+				t.Errorf("Expected the output tool to be forced, got %+v", params.ToolChoice)
+			}
+		})
+	}
+}
+
+func TestStreamStructuredOutputTool(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		start := message("")
+		start["content"] = []any{}
+		writeSSE(t, w,
+			map[string]any{"type": "message_start", "message": start},
+			map[string]any{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "text", "text": ""}},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "text_delta", "text": "Let me answer."}},
+			map[string]any{"type": "content_block_stop", "index": 0},
+			map[string]any{"type": "content_block_start", "index": 1, "content_block": map[string]any{"type": "tool_use", "id": "toolu_1", "name": structuredOutputToolName, "input": map[string]any{}}},
+			map[string]any{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "input_json_delta", "partial_json": `{"city":`}},
+			map[string]any{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "input_json_delta", "partial_json": `"Paris"}`}},
+			map[string]any{"type": "content_block_stop", "index": 1},
+			map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"}, "usage": map[string]any{"output_tokens": 5}},
+			map[string]any{"type": "message_stop"},
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test", StreamToolCalls: true})
+	responses := collect(t, m, outputRequest(), true)
+
+	// The text before the tool is dropped from the final response, so it is not streamed
+	if len(responses) != 3 {
+		t.Fatalf("Expected 2 JSON partials and a final response, got %d", len(responses))
+	}
+	var streamed strings.Builder
+	for _, resp := range responses[:2] {
+		if !resp.Partial || resp.Content.Parts[0].FunctionCall != nil {
+			t.Errorf("Expected text partial, got %+v", resp.Content.Parts[0])
+		}
+		streamed.WriteString(resp.Content.Parts[0].Text)
+	}
+	if streamed.String() != `{"city":"Paris"}` {
+		t.Errorf("Expected streamed JSON, got %q", streamed.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(responses[2].Content.Parts[0].Text), &got); err != nil || got["city"] != "Paris" {
+		t.Errorf("Expected final JSON text, got %q", responses[2].Content.Parts[0].Text)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"fmt"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/jsonschema"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// convertInputSchema converts a function declaration's parameters (a
+// genai.Schema, raw map or any JSON-serializable schema such as
+// *jsonschema.Schema) to a tool input schema. Anthropic requires an object at
+// the root; keywords other than properties and required are kept as extra fields.
+func convertInputSchema(params any) (anthropic.ToolInputSchemaParam, error) {
+	inputSchema := anthropic.ToolInputSchemaParam{Properties: map[string]any{}}
+
+	schema, err := jsonschema.ToMap(params)
+	if err != nil || schema == nil {
+		return inputSchema, err
+	}
+	if err := validateSchema(schema); err != nil {
+		return inputSchema, err
+	}
+	if t, ok := schema["type"]; ok {
+		if single, _ := jsonschema.SingleType(schema); single != "object" {
+			return inputSchema, fmt.Errorf("%w: tool input schema must be of type object, got %v", ErrInvalidSchema, t)
+		}
+	}
+	removePropertyOrdering(schema)
+
+	if props, ok := schema["properties"]; ok {
+		inputSchema.Properties = props
+	}
+	inputSchema.Required = jsonschema.StringList(schema["required"])
+
+	delete(schema, "type")
+	delete(schema, "properties")
+	delete(schema, "required")
+	if len(schema) > 0 {
+		inputSchema.ExtraFields = schema
+	}
+
+	return inputSchema, nil
+}
+
+// validateSchema checks the structure of the keywords this package relies on,
+// so malformed schemas fail here with a descriptive error instead of being
+// rejected by the API or silently dropped.
+func validateSchema(schema map[string]any) error {
+	switch t := schema["type"].(type) {
+	case nil:
+	case string:
+		if !jsonschema.IsType(t) {
+			return fmt.Errorf("%w: unsupported type %q", ErrInvalidSchema, t)
+		}
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); !ok || !jsonschema.IsType(s) {
+				return fmt.Errorf("%w: unsupported type %v", ErrInvalidSchema, item)
+			}
+		}
+	case []string:
+		for _, item := range t {
+			if !jsonschema.IsType(item) {
+				return fmt.Errorf("%w: unsupported type %q", ErrInvalidSchema, item)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: type must be a string or a list of strings, got %T", ErrInvalidSchema, t)
+	}
+
+	if props, ok := schema["properties"]; ok {
+		propsMap, ok := props.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: properties must be an object, got %T", ErrInvalidSchema, props)
+		}
+		for name, prop := range propsMap {
+			if err := validateSubschema(prop); err != nil {
+				return fmt.Errorf("property %q: %w", name, err)
+			}
+		}
+	}
+
+	if required, ok := schema["required"]; ok {
+		switch list := required.(type) {
+		case []string:
+		case []any:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return fmt.Errorf("%w: required must be a list of strings, got item %v", ErrInvalidSchema, item)
+				}
+			}
+		default:
+			return fmt.Errorf("%w: required must be a list of strings, got %T", ErrInvalidSchema, required)
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if _, ok := enum.([]any); !ok {
+			return fmt.Errorf("%w: enum must be a list, got %T", ErrInvalidSchema, enum)
+		}
+	}
+
+	for _, key := range []string{"$defs", "definitions"} {
+		if defs, ok := schema[key]; ok {
+			defsMap, ok := defs.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%w: %s must be an object, got %T", ErrInvalidSchema, key, defs)
+			}
+			for name, def := range defsMap {
+				if err := validateSubschema(def); err != nil {
+					return fmt.Errorf("%s %q: %w", key, name, err)
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := schema[key]; ok {
+			if err := validateSubschema(sub); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	for _, key := range []string{"anyOf", "oneOf", "allOf", "prefixItems"} {
+		if list, ok := schema[key]; ok {
+			subs, ok := list.([]any)
+			if !ok {
+				return fmt.Errorf("%w: %s must be a list, got %T", ErrInvalidSchema, key, list)
+			}
+			for i, sub := range subs {
+				if err := validateSubschema(sub); err != nil {
+					return fmt.Errorf("%s[%d]: %w", key, i, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSubschema validates a nested schema, which may also be a boolean.
+func validateSubschema(schema any) error {
+	switch s := schema.(type) {
+	case bool:
+		return nil
+	case map[string]any:
+		return validateSchema(s)
+	}
+	return fmt.Errorf("%w: schema must be an object or a boolean, got %T", ErrInvalidSchema, schema)
+}
+
+// removePropertyOrdering recursively drops Gemini's propertyOrdering keyword,
+// which is not JSON schema.
+func removePropertyOrdering(schema map[string]any) {
+	delete(schema, "propertyOrdering")
+	jsonschema.ForEachSubschema(schema, removePropertyOrdering)
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// toolSchemaJSON returns the input_schema of the single tool built for decl.
+func toolSchemaJSON(t *testing.T, decl *genai.FunctionDeclaration) map[string]any {
+	t.Helper()
+	m := New(Config{ModelName: "claude-test"})
+	tools, err := m.convertTools([]*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{decl}}})
+	if err != nil {
+		t.Fatalf("convertTools failed: %v", err)
+	}
+	data, err := json.Marshal(tools[0].OfTool.InputSchema)
+	if err != nil {
+		t.Fatalf("Failed to marshal input schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Failed to decode input schema: %v", err)
+	}
+	return schema
+}
+
+func TestConvertToolsJSONSchema(t *testing.T) {
+	// Decoded JSON reads "required" as []any
+	var params any
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"city": {"type": "string", "description": "City name"},
+			"unit": {"enum": ["c", "f"]},
+			"address": {"$ref": "#/$defs/address"}
+		},
+		"required": ["city"],
+		"additionalProperties": false,
+		"$defs": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}}
+	}`), &params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := toolSchemaJSON(t, &genai.FunctionDeclaration{Name: "get_weather", ParametersJsonSchema: params})
+
+	if schema["type"] != "object" || schema["additionalProperties"] != false {
+		t.Errorf("Unexpected root schema: %v", schema)
+	}
+	if required, _ := schema["required"].([]any); len(required) != 1 || required[0] != "city" {
+		t.Errorf("Expected required [city], got %v", schema["required"])
+	}
+	props := schema["properties"].(map[string]any)
+	if props["city"].(map[string]any)["description"] != "City name" {
+		t.Errorf("Expected description to be kept, got %v", props["city"])
+	}
+	if enum, _ := props["unit"].(map[string]any)["enum"].([]any); len(enum) != 2 {
+		t.Errorf("Expected enum to be kept, got %v", props["unit"])
+	}
+	if _, ok := schema["$defs"].(map[string]any)["address"]; !ok {
+		t.Errorf("Expected $defs to be kept, got %v", schema["$defs"])
+	}
+}
+
+func TestConvertToolsGenaiSchema(t *testing.T) {
+	schema := toolSchemaJSON(t, &genai.FunctionDeclaration{
+		Name: "search",
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"query": {Type: genai.TypeString, Description: "Search terms"},
+				"limit": {Type: genai.TypeInteger, Minimum: genai.Ptr(1.0), Nullable: genai.Ptr(true)},
+				"tags":  {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: []string{"a", "b"}}},
+			},
+			Required:         []string{"query"},
+			PropertyOrdering: []string{"query", "limit", "tags"},
+		},
+	})
+
+	props := schema["properties"].(map[string]any)
+	if props["query"].(map[string]any)["description"] != "Search terms" {
+		t.Errorf("Unexpected query schema: %v", props["query"])
+	}
+	limit := props["limit"].(map[string]any)
+	if types, _ := limit["type"].([]any); len(types) != 2 || types[1] != "null" || limit["minimum"] != 1.0 {
+		t.Errorf("Unexpected limit schema: %v", limit)
+	}
+	if enum, _ := props["tags"].(map[string]any)["items"].(map[string]any)["enum"].([]any); len(enum) != 2 {
+		t.Errorf("Unexpected tags schema: %v", props["tags"])
+	}
+	if required, _ := schema["required"].([]any); len(required) != 1 || required[0] != "query" {
+		t.Errorf("Expected required [query], got %v", schema["required"])
+	}
+	if _, ok := schema["propertyOrdering"]; ok {
+		t.Errorf("Expected propertyOrdering to be dropped, got %v", schema)
+	}
+
+	// No parameters still sends an empty object schema
+	empty := toolSchemaJSON(t, &genai.FunctionDeclaration{Name: "ping"})
+	if empty["type"] != "object" || len(empty["properties"].(map[string]any)) != 0 {
+		t.Errorf("Unexpected empty schema: %v", empty)
+	}
+}
+
+func TestConvertToolsTypeList(t *testing.T) {
+	for _, params := range []any{
+		map[string]any{"type": []any{"object"}, "properties": map[string]any{"city": map[string]any{"type": []string{"string"}}}},
+		map[string]any{"type": []string{"object"}},
+	} {
+		schema := toolSchemaJSON(t, &genai.FunctionDeclaration{Name: "get_weather", ParametersJsonSchema: params})
+		if schema["type"] != "object" {
+			t.Errorf("Expected an object input schema for %v, got %v", params, schema)
+		}
+	}
+}
+
+func TestConvertToolsInvalidSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		params any
+		want   string
+	}{
+		{"not an object", map[string]any{"type": "string"}, "must be of type object"},
+		{"nullable object", map[string]any{"type": []any{"object", "null"}}, "must be of type object"},
+		{"bad type", map[string]any{"type": "object", "properties": map[string]any{"x": map[string]any{"type": "date"}}}, `property "x"`},
+		{"bad required", map[string]any{"type": "object", "required": "x"}, "required must be a list"},
+		{"bad properties", map[string]any{"type": "object", "properties": []any{"x"}}, "properties must be an object"},
+		{"bad genai type", &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{"x": {Type: "DATE"}}}, `property "x"`},
+		{"not JSON", map[string]any{"type": "object", "default": func() {}}, "invalid JSON schema"},
+	}
+
+	m := New(Config{ModelName: "claude-test"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.convertTools([]*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "bad_tool", ParametersJsonSchema: tt.params},
+			}}})
+			if !errors.Is(err, ErrInvalidSchema) || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "bad_tool") {
+				t.Errorf("Expected ErrInvalidSchema mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// streamingToolUse holds the state of a tool_use block being streamed.
+type streamingToolUse struct {
+	index int64
+	id    string
+	name  string
+	input strings.Builder
+}
+
+// toolUseStream tracks streamed tool_use blocks by content block index.
+type toolUseStream struct {
+	calls map[int64]*streamingToolUse
+}
+
+// newToolUseStream creates an empty tool_use tracker.
+func newToolUseStream() *toolUseStream {
+	return &toolUseStream{calls: make(map[int64]*streamingToolUse)}
+}
+
+// update folds a stream event into the tracked state and returns the partial
+// response to yield, if any: one when a tool_use block starts, one per
+// input_json_delta, and one with the parsed input when the block stops.
+func (s *toolUseStream) update(event anthropic.MessageStreamEventUnion) *model.LLMResponse {
+	switch eventVariant := event.AsAny().(type) {
+	case anthropic.ContentBlockStartEvent:
+		// The structured output tool is streamed as text instead
+		if eventVariant.ContentBlock.Type != "tool_use" || eventVariant.ContentBlock.Name == structuredOutputToolName {
+			return nil
+		}
+		call := &streamingToolUse{
+			index: eventVariant.Index,
+			id:    eventVariant.ContentBlock.ID,
+			name:  eventVariant.ContentBlock.Name,
+		}
+		s.calls[call.index] = call
+		return call.partialResponse("")
+
+	case anthropic.ContentBlockDeltaEvent:
+		call, exists := s.calls[eventVariant.Index]
+		if !exists {
+			return nil
+		}
+		if delta, ok := eventVariant.Delta.AsAny().(anthropic.InputJSONDelta); ok && delta.PartialJSON != "" {
+			call.input.WriteString(delta.PartialJSON)
+			return call.partialResponse(delta.PartialJSON)
+		}
+
+	case anthropic.ContentBlockStopEvent:
+		call, exists := s.calls[eventVariant.Index]
+		if !exists {
+			return nil
+		}
+		delete(s.calls, eventVariant.Index)
+		return call.completeResponse()
+	}
+
+	return nil
+}
+
+// partialResponse builds a partial response carrying the latest input fragment.
+func (c *streamingToolUse) partialResponse(fragment string) *model.LLMResponse {
+	return c.response(toolcall.Delta{
+		Index:          int(c.index),
+		ID:             c.id,
+		Name:           c.name,
+		ArgumentsDelta: fragment,
+	})
+}
+
+// completeResponse builds the partial response announcing that the tool
+// input is complete, with the parsed input attached.
+func (c *streamingToolUse) completeResponse() *model.LLMResponse {
+	input := json.RawMessage(c.input.String())
+	if len(input) == 0 {
+		input = emptyJSONObject
+	}
+	return c.response(toolcall.Delta{
+		Index: int(c.index),
+		ID:    c.id,
+		Name:  c.name,
+		Done:  true,
+		Args:  convertToolInput(input),
+	})
+}
+
+// response wraps a toolcall.Delta into a partial LLMResponse. The content has
+// no parts, so ADK flows forward the event without executing anything.
+func (c *streamingToolUse) response(delta toolcall.Delta) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:        &genai.Content{Role: genai.RoleModel},
+		CustomMetadata: map[string]any{toolcall.DeltaKey: delta},
+		Partial:        true,
+		TurnComplete:   false,
+	}
+}
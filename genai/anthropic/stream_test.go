@@ -0,0 +1,93 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+)
+
+// toolUseEvents is a stream with a text block followed by a tool_use block.
+func toolUseEvents() []map[string]any {
+	start := message("")
+	start["content"] = []any{}
+	return []map[string]any{
+		{"type": "message_start", "message": start},
+		{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "text", "text": ""}},
+		{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "text_delta", "text": "Checking"}},
+		{"type": "content_block_stop", "index": 0},
+		{"type": "content_block_start", "index": 1, "content_block": map[string]any{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": map[string]any{}}},
+		{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "input_json_delta", "partial_json": `{"city":`}},
+		{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "input_json_delta", "partial_json": `"Paris"}`}},
+		{"type": "content_block_stop", "index": 1},
+		{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"}, "usage": map[string]any{"output_tokens": 5}},
+		{"type": "message_stop"},
+	}
+}
+
+func TestStreamToolUse(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w, toolUseEvents()...)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test", StreamToolCalls: true})
+	responses := collect(t, m, userRequest("Weather in Paris?"), true)
+
+	// text, tool start, 2 fragments, tool complete, final
+	if len(responses) != 6 {
+		t.Fatalf("Expected 6 responses, got %d", len(responses))
+	}
+
+	var deltas []toolcall.Delta
+	for i, resp := range responses[1:5] {
+		delta, ok := resp.CustomMetadata[toolcall.DeltaKey].(toolcall.Delta)
+		if !ok || len(resp.Content.Parts) != 0 {
+			t.Fatalf("Response %d: expected a toolcall.Delta without parts, got %+v", i+1, resp)
+		}
+		deltas = append(deltas, delta)
+	}
+
+	if start := deltas[0]; start.ID != "toolu_1" || start.Name != "get_weather" || start.Index != 1 || start.Done || start.ArgumentsDelta != "" {
+		t.Errorf("Expected tool call announcement, got %+v", start)
+	}
+	for i, want := range []string{`{"city":`, `"Paris"}`} {
+		if got := deltas[1+i].ArgumentsDelta; got != want {
+			t.Errorf("Fragment %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if done := deltas[3]; !done.Done || done.Args["city"] != "Paris" {
+		t.Errorf("Expected completed tool call, got %+v", done)
+	}
+
+	final := responses[5]
+	if final.Partial || len(final.Content.Parts) != 2 || final.Content.Parts[1].FunctionCall.Args["city"] != "Paris" {
+		t.Errorf("Unexpected final response: %+v", final)
+	}
+}
+
+func TestStreamToolUseDisabled(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w, toolUseEvents()...)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	responses := collect(t, m, userRequest("Weather in Paris?"), true)
+
+	if len(responses) != 2 {
+		t.Fatalf("Expected text partial and final response, got %d", len(responses))
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/packages/param"
+	"google.golang.org/genai"
+)
+
+// minThinkingBudget is the smallest budget_tokens accepted by Anthropic.
+const minThinkingBudget = 1024
+
+// thinkingLevelBudgets maps genai thinking levels to budget_tokens. HIGH plus
+// defaultMaxTokens stays within the 32k output limit of Claude Opus 4 and 4.1.
+var thinkingLevelBudgets = map[genai.ThinkingLevel]int64{
+	genai.ThinkingLevelMinimal: minThinkingBudget,
+	genai.ThinkingLevelLow:     4096,
+	genai.ThinkingLevelMedium:  10000,
+	genai.ThinkingLevelHigh:    24576,
+}
+
+// thinkingSignature is the payload stored in genai.Part.ThoughtSignature for
+// thinking blocks, so they can be sent back signed on the next turn.
+// Redacted thinking blocks only carry their encrypted data.
+type thinkingSignature struct {
+	Signature    string `json:"signature,omitempty"`
+	RedactedData string `json:"redacted_data,omitempty"`
+}
+
+// resolveThinkingBudget returns the budget_tokens for a genai ThinkingConfig.
+// A zero budget disables thinking; a negative (dynamic) or missing budget
+// falls back to the thinking level, MEDIUM by default.
+func resolveThinkingBudget(cfg *genai.ThinkingConfig) int64 {
+	if cfg.ThinkingBudget != nil && *cfg.ThinkingBudget >= 0 {
+		if *cfg.ThinkingBudget == 0 {
+			return 0
+		}
+		return max(int64(*cfg.ThinkingBudget), minThinkingBudget)
+	}
+	if budget, ok := thinkingLevelBudgets[cfg.ThinkingLevel]; ok {
+		return budget
+	}
+	return thinkingLevelBudgets[genai.ThinkingLevelMedium]
+}
+
+// applyThinkingConfig enables extended thinking on the request. max_tokens
+// includes the thinking budget, which must stay below it: without a
+// MaxOutputTokens from the caller, max_tokens is raised to fit the budget and
+// the answer; otherwise a level budget shrinks to fit, and an explicit budget
+// that does not fit is an error. Sampling settings that thinking does not
+// support are dropped.
+func applyThinkingConfig(params *anthropic.MessageNewParams, cfg *genai.ThinkingConfig, maxOutputTokens int32) error {
+	budget := resolveThinkingBudget(cfg)
+	if budget == 0 {
+		params.Thinking = anthropic.ThinkingConfigParamUnion{OfDisabled: &anthropic.ThinkingConfigDisabledParam{}}
+		return nil
+	}
+
+	fromLevel := cfg.ThinkingBudget == nil || *cfg.ThinkingBudget < 0
+	switch {
+	case maxOutputTokens <= 0:
+		params.MaxTokens = budget + defaultMaxTokens
+	case fromLevel && budget >= params.MaxTokens:
+		// Leave room for the answer when possible
+		budget = max(params.MaxTokens-defaultMaxTokens, minThinkingBudget)
+	}
+	if budget >= params.MaxTokens {
+		return fmt.Errorf("%w: budget %d, max output tokens %d", ErrThinkingBudget, budget, params.MaxTokens)
+	}
+	params.Thinking = anthropic.ThinkingConfigParamOfEnabled(budget)
+
+	// Thinking is not compatible with temperature changes or top_p below 0.95
+	params.Temperature = param.Opt[float64]{}
+	if params.TopP.Valid() && params.TopP.Value < 0.95 {
+		params.TopP = param.Opt[float64]{}
+	}
+	return nil
+}
+
+// convertThinkingBlock converts a thinking block into a signed thought part.
+func convertThinkingBlock(block anthropic.ThinkingBlock) *genai.Part {
+	sig, _ := json.Marshal(thinkingSignature{Signature: block.Signature})
+	return &genai.Part{Text: block.Thinking, Thought: true, ThoughtSignature: sig}
+}
+
+// convertRedactedThinkingBlock converts a redacted thinking block into a
+// thought part without text.
+func convertRedactedThinkingBlock(block anthropic.RedactedThinkingBlock) *genai.Part {
+	sig, _ := json.Marshal(thinkingSignature{RedactedData: block.Data})
+	return &genai.Part{Thought: true, ThoughtSignature: sig}
+}
+
+// convertThoughtToBlock rebuilds a thinking block from a thought part produced
+// by convertThinkingBlock or convertRedactedThinkingBlock. Returns false for
+// thoughts without an Anthropic signature (e.g. from another provider), which
+// cannot be sent back.
+func convertThoughtToBlock(part *genai.Part) (anthropic.ContentBlockParamUnion, bool) {
+	if len(part.ThoughtSignature) == 0 {
+		return anthropic.ContentBlockParamUnion{}, false
+	}
+	var sig thinkingSignature
+	if err := json.Unmarshal(part.ThoughtSignature, &sig); err != nil {
+		return anthropic.ContentBlockParamUnion{}, false
+	}
+
+	switch {
+	case sig.RedactedData != "":
+		return anthropic.NewRedactedThinkingBlock(sig.RedactedData), true
+	case sig.Signature != "":
+		return anthropic.NewThinkingBlock(sig.Signature, part.Text), true
+	}
+	return anthropic.ContentBlockParamUnion{}, false
+}
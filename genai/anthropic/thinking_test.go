@@ -0,0 +1,193 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestThinkingConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           *genai.GenerateContentConfig
+		wantThinking  string
+		wantMaxTokens int64
+	}{
+		{
+			name:          "budget without max tokens",
+			cfg:           &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](8000)}},
+			wantThinking:  `{"budget_tokens":8000,"type":"enabled"}`,
+			wantMaxTokens: 8000 + defaultMaxTokens,
+		},
+		{
+			name:          "budget below minimum",
+			cfg:           &genai.GenerateContentConfig{MaxOutputTokens: 16000, ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](100)}},
+			wantThinking:  `{"budget_tokens":1024,"type":"enabled"}`,
+			wantMaxTokens: 16000,
+		},
+		{
+			name:          "high level within Opus 4 limit",
+			cfg:           &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelHigh}},
+			wantThinking:  `{"budget_tokens":24576,"type":"enabled"}`,
+			wantMaxTokens: 24576 + defaultMaxTokens,
+		},
+		{
+			name:          "level above max tokens",
+			cfg:           &genai.GenerateContentConfig{MaxOutputTokens: 8192, ThinkingConfig: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelMedium}},
+			wantThinking:  `{"budget_tokens":4096,"type":"enabled"}`,
+			wantMaxTokens: 8192,
+		},
+		{
+			name:          "level",
+			cfg:           &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelLow}},
+			wantThinking:  `{"budget_tokens":4096,"type":"enabled"}`,
+			wantMaxTokens: 4096 + defaultMaxTokens,
+		},
+		{
+			name:          "disabled",
+			cfg:           &genai.GenerateContentConfig{ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](0)}},
+			wantThinking:  `{"type":"disabled"}`,
+			wantMaxTokens: defaultMaxTokens,
+		},
+	}
+
+	m := New(Config{ModelName: "claude-test"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Temperature = genai.Ptr[float32](0.2)
+			params, err := m.buildMessageParams(&model.LLMRequest{Contents: userRequest("hi").Contents, Config: tt.cfg})
+			if err != nil {
+				t.Fatalf("buildMessageParams failed: %v", err)
+			}
+
+			got, _ := json.Marshal(params.Thinking)
+			if string(got) != tt.wantThinking {
+				t.Errorf("Expected thinking %s, got %s", tt.wantThinking, got)
+			}
+			if params.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("Expected max_tokens %d, got %d", tt.wantMaxTokens, params.MaxTokens)
+			}
+			if enabled := params.Thinking.OfEnabled != nil; enabled == params.Temperature.Valid() {
+				t.Errorf("Expected temperature to be dropped only with thinking enabled")
+			}
+		})
+	}
+}
+
+func TestThinkingBudgetAboveMaxTokens(t *testing.T) {
+	m := New(Config{ModelName: "claude-test"})
+	for _, cfg := range []*genai.GenerateContentConfig{
+		{MaxOutputTokens: 2048, ThinkingConfig: &genai.ThinkingConfig{ThinkingBudget: genai.Ptr[int32](8000)}},
+		{MaxOutputTokens: 1024, ThinkingConfig: &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelLow}},
+	} {
+		_, err := m.buildMessageParams(&model.LLMRequest{Contents: userRequest("hi").Contents, Config: cfg})
+		if !errors.Is(err, ErrThinkingBudget) {
+			t.Errorf("Expected ErrThinkingBudget with max output tokens %d, got %v", cfg.MaxOutputTokens, err)
+		}
+	}
+}
+
+func TestThinkingRoundTrip(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reqBody = nil
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("tool_use",
+			map[string]any{"type": "thinking", "thinking": "Need the weather", "signature": "sig_1"},
+			map[string]any{"type": "redacted_thinking", "data": "encrypted"},
+			map[string]any{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": map[string]any{"city": "Paris"}},
+		))
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	req := userRequest("Weather in Paris?")
+	resp := collect(t, m, req, false)[0]
+
+	parts := resp.Content.Parts
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	if !parts[0].Thought || parts[0].Text != "Need the weather" || len(parts[0].ThoughtSignature) == 0 {
+		t.Errorf("Expected signed thought, got %+v", parts[0])
+	}
+	if !parts[1].Thought || parts[1].Text != "" || len(parts[1].ThoughtSignature) == 0 {
+		t.Errorf("Expected redacted thought, got %+v", parts[1])
+	}
+
+	// Send the turn back with the tool result
+	req.Contents = append(req.Contents, resp.Content, &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			ID: "toolu_1", Name: "get_weather", Response: map[string]any{"temp": 20},
+		}}},
+	})
+	collect(t, m, req, false)
+
+	messages := reqBody["messages"].([]any)
+	blocks := messages[1].(map[string]any)["content"].([]any)
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 assistant blocks, got %v", blocks)
+	}
+	thinking := blocks[0].(map[string]any)
+	if thinking["type"] != "thinking" || thinking["signature"] != "sig_1" || thinking["thinking"] != "Need the weather" {
+		t.Errorf("Unexpected thinking block: %v", thinking)
+	}
+	if redacted := blocks[1].(map[string]any); redacted["type"] != "redacted_thinking" || redacted["data"] != "encrypted" {
+		t.Errorf("Unexpected redacted thinking block: %v", redacted)
+	}
+}
+
+func TestStreamThinking(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		start := message("")
+		start["content"] = []any{}
+		writeSSE(t, w,
+			map[string]any{"type": "message_start", "message": start},
+			map[string]any{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "thinking", "thinking": "", "signature": ""}},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "thinking_delta", "thinking": "Let me think"}},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "signature_delta", "signature": "sig_1"}},
+			map[string]any{"type": "content_block_stop", "index": 0},
+			map[string]any{"type": "content_block_start", "index": 1, "content_block": map[string]any{"type": "text", "text": ""}},
+			map[string]any{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "text_delta", "text": "Hello"}},
+			map[string]any{"type": "content_block_stop", "index": 1},
+			map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "end_turn"}, "usage": map[string]any{"output_tokens": 5}},
+			map[string]any{"type": "message_stop"},
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	responses := collect(t, m, userRequest("hi"), true)
+
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d", len(responses))
+	}
+	if part := responses[0].Content.Parts[0]; !part.Thought || part.Text != "Let me think" || !responses[0].Partial {
+		t.Errorf("Expected partial thought, got %+v", part)
+	}
+	final := responses[2]
+	if final.Partial || len(final.Content.Parts) != 2 {
+		t.Fatalf("Unexpected final response: %+v", final)
+	}
+	var sig thinkingSignature
+	if err := json.Unmarshal(final.Content.Parts[0].ThoughtSignature, &sig); err != nil || sig.Signature != "sig_1" {
+		t.Errorf("Expected accumulated signature, got %s", final.Content.Parts[0].ThoughtSignature)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// CountTokens returns the number of input tokens the request would use, as
+// counted by /v1/messages/count_tokens. The request is built exactly as for
+// GenerateContent, so the system prompt, tools and thinking are included.
+// Bedrock does not expose this endpoint and returns ErrCountTokensUnsupported.
+func (m *Model) CountTokens(ctx context.Context, req *model.LLMRequest) (*genai.CountTokensResponse, error) {
+	if m.bedrock {
+		return nil, ErrCountTokensUnsupported
+	}
+
+	params, err := m.buildMessageParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.Messages.CountTokens(ctx, countTokensParams(params), requestOptions(params)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tokens: %w", classifyError(err))
+	}
+
+	return &genai.CountTokensResponse{TotalTokens: int32(resp.InputTokens)}, nil
+}
+
+// countTokensParams copies the fields of a Messages request that count_tokens accepts.
+func countTokensParams(params anthropic.MessageNewParams) anthropic.MessageCountTokensParams {
+	countParams := anthropic.MessageCountTokensParams{
+		Model:      params.Model,
+		Messages:   params.Messages,
+		Thinking:   params.Thinking,
+		ToolChoice: params.ToolChoice,
+	}
+	if len(params.System) > 0 {
+		countParams.System = anthropic.MessageCountTokensParamsSystemUnion{OfTextBlockArray: params.System}
+	}
+	for _, tool := range params.Tools {
+		if tool.OfTool != nil {
+			countParams.Tools = append(countParams.Tools, anthropic.MessageCountTokensToolUnionParam{OfTool: tool.OfTool})
+		}
+	}
+	if extraFields := params.ExtraFields(); len(extraFields) > 0 {
+		countParams.SetExtraFields(extraFields)
+	}
+	return countParams
+}
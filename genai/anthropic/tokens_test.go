@@ -0,0 +1,64 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestCountTokens(t *testing.T) {
+	var path string
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, map[string]any{"input_tokens": 42})
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "claude-test"})
+	req := userRequest("Weather in Paris?")
+	req.Config = &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("You are a weather bot.", genai.RoleUser),
+		Tools:             []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}},
+	}
+
+	resp, err := m.CountTokens(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+	if resp.TotalTokens != 42 {
+		t.Errorf("Expected 42 tokens, got %d", resp.TotalTokens)
+	}
+	if path != "/v1/messages/count_tokens" {
+		t.Errorf("Expected count_tokens endpoint, got %s", path)
+	}
+	if reqBody["model"] != "claude-test" || reqBody["system"] == nil || len(reqBody["tools"].([]any)) != 1 || len(reqBody["messages"].([]any)) != 1 {
+		t.Errorf("Expected the full request to be counted, got %v", reqBody)
+	}
+	if _, ok := reqBody["max_tokens"]; ok {
+		t.Errorf("Expected no max_tokens in count_tokens request, got %v", reqBody)
+	}
+
+	bedrock := New(Config{ModelName: "claude-test", Bedrock: &BedrockConfig{Region: "us-east-1"}})
+	if _, err := bedrock.CountTokens(context.Background(), req); !errors.Is(err, ErrCountTokensUnsupported) {
+		t.Errorf("Expected ErrCountTokensUnsupported on Bedrock, got %v", err)
+	}
+}
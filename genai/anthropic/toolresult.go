@@ -0,0 +1,124 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/genai"
+)
+
+// ToolErrorKey is the FunctionResponse.Response key that marks a failed tool
+// call. ADK sets it when a tool returns an error; tools can also set it
+// themselves. A non-empty string, an error or a non-empty map is sent as a
+// tool_result with is_error; other values (false, 0, "") are a normal result.
+const ToolErrorKey = "error"
+
+// toolError returns the message of the error reported by a function
+// response, if any.
+func toolError(response map[string]any) (string, bool, error) {
+	switch value := response[ToolErrorKey].(type) {
+	case string:
+		return value, value != "", nil
+	case error:
+		return value.Error(), true, nil
+	case map[string]any:
+		if len(value) == 0 {
+			return "", false, nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to marshal function response: %w", err)
+		}
+		return string(data), true, nil
+	}
+	return "", false, nil
+}
+
+// convertFunctionResponse builds the tool_result block of a function response.
+// Errors are sent as their message with is_error set. Otherwise the response
+// is sent as JSON text, followed by its multimodal parts (e.g. screenshots) as
+// image and document blocks.
+func (m *Model) convertFunctionResponse(response *genai.FunctionResponse) (anthropic.ContentBlockParamUnion, error) {
+	toolResult := &anthropic.ToolResultBlockParam{ToolUseID: sanitizeToolID(response.ID)}
+
+	message, isError, err := toolError(response.Response)
+	if err != nil {
+		return anthropic.ContentBlockParamUnion{}, err
+	}
+	if isError {
+		toolResult.IsError = anthropic.Bool(true)
+		toolResult.Content = []anthropic.ToolResultBlockParamContentUnion{{OfText: &anthropic.TextBlockParam{Text: message}}}
+		return anthropic.ContentBlockParamUnion{OfToolResult: toolResult}, nil
+	}
+
+	if len(response.Response) > 0 || len(response.Parts) == 0 {
+		responseJSON, err := json.Marshal(response.Response)
+		if err != nil {
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("failed to marshal function response: %w", err)
+		}
+		toolResult.Content = append(toolResult.Content, anthropic.ToolResultBlockParamContentUnion{
+			OfText: &anthropic.TextBlockParam{Text: string(responseJSON)},
+		})
+	}
+
+	for _, part := range response.Parts {
+		content, err := m.convertFunctionResponsePart(part)
+		if err != nil {
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("function response %q: %w", response.Name, err)
+		}
+		toolResult.Content = append(toolResult.Content, content)
+	}
+
+	return anthropic.ContentBlockParamUnion{OfToolResult: toolResult}, nil
+}
+
+// convertFunctionResponsePart converts a multimodal function response part to
+// tool_result content, reusing the conversion of user media parts.
+func (m *Model) convertFunctionResponsePart(part *genai.FunctionResponsePart) (anthropic.ToolResultBlockParamContentUnion, error) {
+	var mediaPart genai.Part
+	switch {
+	case part == nil:
+		return anthropic.ToolResultBlockParamContentUnion{}, fmt.Errorf("%w: empty function response part", ErrUnsupportedPart)
+	case part.InlineData != nil:
+		mediaPart.InlineData = &genai.Blob{
+			MIMEType:    part.InlineData.MIMEType,
+			Data:        part.InlineData.Data,
+			DisplayName: part.InlineData.DisplayName,
+		}
+	case part.FileData != nil:
+		mediaPart.FileData = &genai.FileData{
+			FileURI:     part.FileData.FileURI,
+			MIMEType:    part.FileData.MIMEType,
+			DisplayName: part.FileData.DisplayName,
+		}
+	default:
+		return anthropic.ToolResultBlockParamContentUnion{}, fmt.Errorf("%w: empty function response part", ErrUnsupportedPart)
+	}
+
+	block, err := convertMediaPart(&mediaPart, m.citations)
+	if err != nil {
+		return anthropic.ToolResultBlockParamContentUnion{}, err
+	}
+	switch {
+	case block.OfImage != nil:
+		return anthropic.ToolResultBlockParamContentUnion{OfImage: block.OfImage}, nil
+	case block.OfDocument != nil:
+		return anthropic.ToolResultBlockParamContentUnion{OfDocument: block.OfDocument}, nil
+	}
+	return anthropic.ToolResultBlockParamContentUnion{}, fmt.Errorf("%w: function response part", ErrUnsupportedPart)
+}
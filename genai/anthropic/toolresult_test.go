@@ -0,0 +1,78 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestConvertFunctionResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *genai.FunctionResponse
+		want     string
+	}{
+		{"result", &genai.FunctionResponse{ID: "toolu_1", Name: "get_weather", Response: map[string]any{"temp": 20}},
+			`{"tool_use_id":"toolu_1","content":[{"text":"{\"temp\":20}","type":"text"}],"type":"tool_result"}`},
+		{"error", &genai.FunctionResponse{ID: "toolu_1", Name: "get_weather", Response: map[string]any{"error": "city not found"}},
+			`{"tool_use_id":"toolu_1","is_error":true,"content":[{"text":"city not found","type":"text"}],"type":"tool_result"}`},
+		{"structured error", &genai.FunctionResponse{ID: "toolu_1", Name: "get_weather", Response: map[string]any{"error": map[string]any{"code": 404}}},
+			`{"tool_use_id":"toolu_1","is_error":true,"content":[{"text":"{\"code\":404}","type":"text"}],"type":"tool_result"}`},
+		{"empty error", &genai.FunctionResponse{ID: "toolu_1", Name: "get_weather", Response: map[string]any{"error": "", "temp": 20}},
+			`{"tool_use_id":"toolu_1","content":[{"text":"{\"error\":\"\",\"temp\":20}","type":"text"}],"type":"tool_result"}`},
+		{"error value", &genai.FunctionResponse{ID: "toolu_1", Name: "get_weather", Response: map[string]any{"error": errors.New("timeout")}},
+			`{"tool_use_id":"toolu_1","is_error":true,"content":[{"text":"timeout","type":"text"}],"type":"tool_result"}`},
+		{"false error", &genai.FunctionResponse{ID: "toolu_1", Name: "check", Response: map[string]any{"error": false}},
+			`{"tool_use_id":"toolu_1","content":[{"text":"{\"error\":false}","type":"text"}],"type":"tool_result"}`},
+		{"zero error", &genai.FunctionResponse{ID: "toolu_1", Name: "count", Response: map[string]any{"error": 0}},
+			`{"tool_use_id":"toolu_1","content":[{"text":"{\"error\":0}","type":"text"}],"type":"tool_result"}`},
+		{"empty map error", &genai.FunctionResponse{ID: "toolu_1", Name: "check", Response: map[string]any{"error": map[string]any{}}},
+			`{"tool_use_id":"toolu_1","content":[{"text":"{\"error\":{}}","type":"text"}],"type":"tool_result"}`},
+		{"screenshot", &genai.FunctionResponse{ID: "toolu_1", Name: "screenshot", Response: map[string]any{"url": "https://example.com"}, Parts: []*genai.FunctionResponsePart{
+			genai.NewFunctionResponsePartFromBytes([]byte("png"), "image/png"),
+		}}, `{"tool_use_id":"toolu_1","content":[{"text":"{\"url\":\"https://example.com\"}","type":"text"},{"source":{"data":"cG5n","media_type":"image/png","type":"base64"},"type":"image"}],"type":"tool_result"}`},
+		{"parts only", &genai.FunctionResponse{ID: "toolu_1", Name: "read_file", Parts: []*genai.FunctionResponsePart{
+			genai.NewFunctionResponsePartFromURI("https://example.com/doc.pdf", "application/pdf"),
+		}}, `{"tool_use_id":"toolu_1","content":[{"source":{"url":"https://example.com/doc.pdf","type":"url"},"type":"document"}],"type":"tool_result"}`},
+	}
+
+	m := New(Config{ModelName: "claude-test"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := m.convertFunctionResponse(tt.response)
+			if err != nil {
+				t.Fatalf("convertFunctionResponse failed: %v", err)
+			}
+			got, err := json.Marshal(block)
+			if err != nil {
+				t.Fatalf("Failed to marshal block: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	_, err := m.convertFunctionResponse(&genai.FunctionResponse{ID: "toolu_1", Name: "record", Parts: []*genai.FunctionResponsePart{
+		genai.NewFunctionResponsePartFromBytes([]byte("wav"), "audio/wav"),
+	}})
+	if !errors.Is(err, ErrUnsupportedPart) {
+		t.Errorf("Expected ErrUnsupportedPart for audio part, got %v", err)
+	}
+}
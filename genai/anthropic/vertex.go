@@ -0,0 +1,83 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/vertex"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// vertexScope is the OAuth scope required by Vertex AI.
+const vertexScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// VertexConfig configures access to Claude through Google Vertex AI.
+// Requests are authenticated with OAuth access tokens and ModelName is a
+// Vertex model ID (e.g., "claude-sonnet-4-5@20250929").
+type VertexConfig struct {
+	// Region is the Vertex AI location (e.g., "us-east5", or "global").
+	Region string
+	// ProjectID is the Google Cloud project to bill.
+	ProjectID string
+	// TokenSource provides the OAuth access tokens. If nil, Application
+	// Default Credentials are loaded on the first request.
+	TokenSource oauth2.TokenSource
+	// BaseURL overrides the endpoint (optional, e.g. for Private Service
+	// Connect or a local stand-in). Defaults to the regional Vertex AI endpoint.
+	BaseURL string
+}
+
+// requestOptions returns the client options that route and authenticate
+// requests for Vertex AI. Anthropic API credentials from the environment are dropped.
+func (c VertexConfig) requestOptions() []option.RequestOption {
+	tokenSource := c.TokenSource
+	if tokenSource == nil {
+		tokenSource = oauth2.ReuseTokenSource(nil, &defaultGoogleTokenSource{})
+	}
+
+	opts := []option.RequestOption{
+		option.WithHeaderDel("X-Api-Key"),
+		vertex.WithCredentials(context.Background(), c.Region, c.ProjectID, &google.Credentials{
+			ProjectID:   c.ProjectID,
+			TokenSource: tokenSource,
+		}),
+	}
+	if c.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(c.BaseURL))
+	}
+	return opts
+}
+
+// defaultGoogleTokenSource loads Application Default Credentials when the
+// first token is needed, so missing credentials fail requests instead of New.
+type defaultGoogleTokenSource struct {
+	source oauth2.TokenSource
+}
+
+// Token implements oauth2.TokenSource.
+func (s *defaultGoogleTokenSource) Token() (*oauth2.Token, error) {
+	if s.source == nil {
+		creds, err := google.FindDefaultCredentials(context.Background(), vertexScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find Google default credentials: %w", err)
+		}
+		s.source = creds.TokenSource
+	}
+	return s.source.Token()
+}
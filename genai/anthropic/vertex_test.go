@@ -0,0 +1,62 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anthropic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestVertex(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "anthropic-key")
+
+	var gotReq *http.Request
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		writeJSON(w, message("end_turn", map[string]any{"type": "text", "text": "Hi"}))
+	})
+
+	m := New(Config{
+		ModelName: "claude-sonnet-4-5@20250929",
+		Vertex: &VertexConfig{
+			Region:      "us-east5",
+			ProjectID:   "my-project",
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "vertex-token"}),
+			BaseURL:     server.URL,
+		},
+	})
+	responses := collect(t, m, userRequest("hello"), false)
+
+	if gotReq.URL.Path != "/v1/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:rawPredict" {
+		t.Errorf("Expected Vertex rawPredict path, got %s", gotReq.URL.Path)
+	}
+	if auth := gotReq.Header.Get("Authorization"); auth != "Bearer vertex-token" {
+		t.Errorf("Expected OAuth Bearer token, got %q", auth)
+	}
+	if gotReq.Header.Get("X-Api-Key") != "" {
+		t.Errorf("Expected Anthropic API key not to be sent to Vertex")
+	}
+	if reqBody["anthropic_version"] == nil || reqBody["model"] != nil {
+		t.Errorf("Expected Vertex request body, got %v", reqBody)
+	}
+	if responses[0].Content.Parts[0].Text != "Hi" {
+		t.Errorf("Unexpected response: %+v", responses[0].Content)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonschema converts genai schemas and JSON-serializable schemas to
+// JSON schema maps, and provides the helpers the provider clients use to walk
+// and rewrite them. Provider-specific normalization stays in each client.
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+var ErrInvalidSchema = errors.New("invalid JSON schema")
+
+// typeNames are the type names accepted by JSON schema.
+var typeNames = []string{"string", "number", "integer", "boolean", "array", "object", "null"}
+
+// ToMap converts a genai.Schema, raw map or any JSON-serializable schema
+// (e.g. *jsonschema.Schema) to a JSON schema map. Maps are deep-copied, so
+// later normalization never mutates the caller's declaration.
+func ToMap(schema any) (map[string]any, error) {
+	switch s := schema.(type) {
+	case nil:
+		return nil, nil
+	case *genai.Schema:
+		return Convert(s)
+	case genai.Schema:
+		return Convert(&s)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%w: schema must be a JSON object: %v", ErrInvalidSchema, err)
+	}
+	return result, nil
+}
+
+// Convert recursively converts a genai.Schema to JSON schema format. A nil
+// schema is an empty object. Gemini's propertyOrdering keyword is kept for the
+// clients to apply or drop, and Nullable becomes a null type, enum value or
+// anyOf entry (see MakeNullable).
+func Convert(schema *genai.Schema) (map[string]any, error) {
+	if schema == nil {
+		return map[string]any{"type": "object", "properties": map[string]any{}}, nil
+	}
+
+	result := make(map[string]any)
+
+	if schema.Type != "" && schema.Type != genai.TypeUnspecified {
+		schemaType := strings.ToLower(string(schema.Type))
+		if !IsType(schemaType) {
+			return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidSchema, schema.Type)
+		}
+		result["type"] = schemaType
+	}
+	if schema.Title != "" {
+		result["title"] = schema.Title
+	}
+	if schema.Description != "" {
+		result["description"] = schema.Description
+	}
+	if schema.Format != "" {
+		result["format"] = schema.Format
+	}
+	if schema.Pattern != "" {
+		result["pattern"] = schema.Pattern
+	}
+	if len(schema.Enum) > 0 {
+		enum := make([]any, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			enum = append(enum, v)
+		}
+		result["enum"] = enum
+	}
+	if schema.Default != nil {
+		result["default"] = schema.Default
+	}
+	if schema.Example != nil {
+		result["examples"] = []any{schema.Example}
+	}
+
+	// Numeric, string, array and object bounds
+	setIfNotNil(result, "minimum", schema.Minimum)
+	setIfNotNil(result, "maximum", schema.Maximum)
+	setIfNotNil(result, "minLength", schema.MinLength)
+	setIfNotNil(result, "maxLength", schema.MaxLength)
+	setIfNotNil(result, "minItems", schema.MinItems)
+	setIfNotNil(result, "maxItems", schema.MaxItems)
+	setIfNotNil(result, "minProperties", schema.MinProperties)
+	setIfNotNil(result, "maxProperties", schema.MaxProperties)
+
+	if len(schema.Properties) > 0 {
+		props := make(map[string]any)
+		for name, propSchema := range schema.Properties {
+			converted, err := Convert(propSchema)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", name, err)
+			}
+			props[name] = converted
+		}
+		result["properties"] = props
+	}
+	if len(schema.Required) > 0 {
+		result["required"] = slices.Clone(schema.Required)
+	}
+	if len(schema.PropertyOrdering) > 0 {
+		result["propertyOrdering"] = slices.Clone(schema.PropertyOrdering)
+	}
+
+	if schema.Items != nil {
+		items, err := Convert(schema.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		result["items"] = items
+	}
+
+	if len(schema.AnyOf) > 0 {
+		anyOf := make([]any, 0, len(schema.AnyOf))
+		for i, sub := range schema.AnyOf {
+			converted, err := Convert(sub)
+			if err != nil {
+				return nil, fmt.Errorf("anyOf[%d]: %w", i, err)
+			}
+			anyOf = append(anyOf, converted)
+		}
+		result["anyOf"] = anyOf
+	}
+
+	if schema.Nullable != nil && *schema.Nullable {
+		MakeNullable(result)
+	}
+
+	return result, nil
+}
+
+// setIfNotNil sets key to the pointed-to value when ptr is not nil.
+func setIfNotNil[T any](schema map[string]any, key string, ptr *T) {
+	if ptr != nil {
+		schema[key] = *ptr
+	}
+}
+
+// IsType reports whether name is a JSON schema type name.
+func IsType(name string) bool {
+	return slices.Contains(typeNames, name)
+}
+
+// HasType reports whether a schema's type is, or includes, the given type.
+func HasType(schema map[string]any, schemaType string) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == schemaType
+	case []any:
+		return slices.Contains(t, any(schemaType))
+	case []string:
+		return slices.Contains(t, schemaType)
+	}
+	return false
+}
+
+// SingleType returns a schema's only type: its type string, or the item of a
+// single-element type list. It returns false for none or several types.
+func SingleType(schema map[string]any) (string, bool) {
+	if t, ok := schema["type"].(string); ok {
+		return t, true
+	}
+	if types := StringList(schema["type"]); len(types) == 1 {
+		return types[0], true
+	}
+	return "", false
+}
+
+// MakeNullable allows null values for a schema, by extending its type, enum or anyOf.
+func MakeNullable(schema map[string]any) {
+	switch t := schema["type"].(type) {
+	case string:
+		if t != "null" {
+			schema["type"] = []any{t, "null"}
+		}
+	case []any:
+		if !slices.Contains(t, any("null")) {
+			schema["type"] = append(t, "null")
+		}
+	case []string:
+		if !slices.Contains(t, "null") {
+			types := make([]any, 0, len(t)+1)
+			for _, s := range t {
+				types = append(types, s)
+			}
+			schema["type"] = append(types, "null")
+		}
+	case nil:
+		if anyOf, ok := schema["anyOf"].([]any); ok {
+			if !slices.ContainsFunc(anyOf, isNullSchema) {
+				schema["anyOf"] = append(anyOf, map[string]any{"type": "null"})
+			}
+		} else if ref, ok := schema["$ref"]; ok {
+			// $ref cannot have siblings in strict mode, so wrap it
+			delete(schema, "$ref")
+			schema["anyOf"] = []any{map[string]any{"$ref": ref}, map[string]any{"type": "null"}}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, nil) {
+		schema["enum"] = append(enum, nil)
+	}
+}
+
+// isNullSchema reports whether a subschema only accepts null.
+func isNullSchema(schema any) bool {
+	m, ok := schema.(map[string]any)
+	return ok && m["type"] == "null"
+}
+
+// ForEachSubschema calls fn on every direct subschema: properties, items,
+// additionalProperties, anyOf/oneOf/allOf entries and $defs/definitions.
+func ForEachSubschema(schema map[string]any, fn func(map[string]any)) {
+	for _, key := range []string{"properties", "$defs", "definitions"} {
+		if defs, ok := schema[key].(map[string]any); ok {
+			for _, sub := range defs {
+				if subMap, ok := sub.(map[string]any); ok {
+					fn(subMap)
+				}
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := schema[key].(map[string]any); ok {
+			fn(sub)
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf", "prefixItems"} {
+		if list, ok := schema[key].([]any); ok {
+			for _, sub := range list {
+				if subMap, ok := sub.(map[string]any); ok {
+					fn(subMap)
+				}
+			}
+		}
+	}
+}
+
+// StringList reads a []string or []any of strings from a decoded schema value.
+func StringList(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestConvertSchema(t *testing.T) {
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"name": {Type: genai.TypeString, Pattern: "^[a-z]+$", MinLength: genai.Ptr[int64](1), MaxLength: genai.Ptr[int64](10)},
+			"age":  {Type: genai.TypeInteger, Minimum: genai.Ptr(0.0), Maximum: genai.Ptr(150.0), Nullable: genai.Ptr(true)},
+			"when": {Type: "string", Format: "date-time", Default: "now"},
+			"tags": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString, Enum: []string{"a", "b"}}, MaxItems: genai.Ptr[int64](3)},
+			"id":   {AnyOf: []*genai.Schema{{Type: genai.TypeString}, {Type: genai.TypeInteger}}, Nullable: genai.Ptr(true)},
+			"kind": {Type: genai.TypeString, Enum: []string{"a", "b"}, Nullable: genai.Ptr(true)},
+		},
+		Required:         []string{"name"},
+		PropertyOrdering: []string{"name", "age"},
+	}
+
+	got, err := Convert(schema)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	props := got["properties"].(map[string]any)
+
+	name := props["name"].(map[string]any)
+	if name["pattern"] != "^[a-z]+$" || name["minLength"] != int64(1) || name["maxLength"] != int64(10) {
+		t.Errorf("Unexpected name schema: %v", name)
+	}
+	age := props["age"].(map[string]any)
+	if fmt.Sprint(age["type"]) != "[integer null]" || age["minimum"] != 0.0 || age["maximum"] != 150.0 {
+		t.Errorf("Unexpected age schema: %v", age)
+	}
+	when := props["when"].(map[string]any)
+	if when["type"] != "string" || when["format"] != "date-time" || when["default"] != "now" {
+		t.Errorf("Unexpected when schema: %v", when)
+	}
+	items := props["tags"].(map[string]any)["items"].(map[string]any)
+	if fmt.Sprint(items["enum"]) != "[a b]" {
+		t.Errorf("Unexpected tags items: %v", items)
+	}
+	if anyOf := props["id"].(map[string]any)["anyOf"].([]any); len(anyOf) != 3 || !isNullSchema(anyOf[2]) {
+		t.Errorf("Expected nullable anyOf, got %v", anyOf)
+	}
+
+	if kind := props["kind"].(map[string]any); fmt.Sprint(kind["type"]) != "[string null]" || fmt.Sprint(kind["enum"]) != "[a b <nil>]" {
+		t.Errorf("Expected nullable enum, got %v", kind)
+	}
+	if fmt.Sprint(got["propertyOrdering"]) != "[name age]" {
+		t.Errorf("Expected propertyOrdering to be kept, got %v", got["propertyOrdering"])
+	}
+
+	_, err = Convert(&genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{"x": {Type: "DATE"}}})
+	if !errors.Is(err, ErrInvalidSchema) || !strings.Contains(err.Error(), `property "x"`) {
+		t.Errorf("Expected ErrInvalidSchema for unknown type, got %v", err)
+	}
+}
+
+func TestSingleType(t *testing.T) {
+	tests := []struct {
+		schemaType any
+		want       string
+		wantOK     bool
+	}{
+		{"object", "object", true},
+		{[]any{"object"}, "object", true},
+		{[]string{"object"}, "object", true},
+		{[]any{"object", "null"}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SingleType(map[string]any{"type": tt.schemaType})
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("SingleType(%v) = %q, %v, want %q, %v", tt.schemaType, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package media classifies the inline and file data parts sent to the
+// provider clients. Which kinds a provider accepts, and how, stays in each
+// client.
+package media
+
+import (
+	"mime"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Kind is the media kind of a MIME type.
+type Kind string
+
+// Media kinds. The zero Kind is media no provider takes as input.
+const (
+	KindImage Kind = "image"
+	KindAudio Kind = "audio"
+	KindPDF   Kind = "pdf"
+	KindText  Kind = "text"
+)
+
+// imageMIMETypes are the image formats accepted by both Claude and OpenAI
+// vision models.
+var imageMIMETypes = map[string]bool{
+	"image/jpg": true, "image/jpeg": true, "image/png": true,
+	"image/gif": true, "image/webp": true,
+}
+
+// IsPart reports whether a part carries inline or file data.
+func IsPart(part *genai.Part) bool {
+	return part.InlineData != nil || part.FileData != nil
+}
+
+// BaseType strips parameters (e.g. "; charset=utf-8") from a MIME type.
+func BaseType(mimeType string) string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return mimeType
+}
+
+// KindOf classifies a MIME type, ignoring its parameters. Images are limited
+// to the formats vision models accept; other image types have no kind.
+func KindOf(mimeType string) Kind {
+	mimeType = BaseType(mimeType)
+	switch {
+	case imageMIMETypes[mimeType]:
+		return KindImage
+	case strings.HasPrefix(mimeType, "audio/"):
+		return KindAudio
+	case mimeType == "application/pdf":
+		return KindPDF
+	case strings.HasPrefix(mimeType, "text/"):
+		return KindText
+	}
+	return ""
+}
+
+// IsHTTPURL reports whether a file URI is an http(s) URL.
+func IsHTTPURL(uri string) bool {
+	scheme, _, ok := strings.Cut(uri, "://")
+	return ok && (strings.EqualFold(scheme, "https") || strings.EqualFold(scheme, "http"))
+}
+
+// IsURL reports whether a file URI is a URL (with a scheme, or a data URL)
+// rather than a provider file ID.
+func IsURL(uri string) bool {
+	return strings.Contains(uri, "://") || strings.HasPrefix(uri, "data:")
+}
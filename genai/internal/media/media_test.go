@@ -0,0 +1,55 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package media
+
+import "testing"
+
+func TestKindOf(t *testing.T) {
+	tests := map[string]Kind{
+		"image/jpeg":                   KindImage,
+		"image/png; foo=bar":           KindImage,
+		"image/tiff":                   "",
+		"audio/wav":                    KindAudio,
+		"application/pdf":              KindPDF,
+		"text/markdown; charset=utf-8": KindText,
+		"application/zip":              "",
+	}
+	for mimeType, want := range tests {
+		if got := KindOf(mimeType); got != want {
+			t.Errorf("KindOf(%q): expected %q, got %q", mimeType, want, got)
+		}
+	}
+}
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		uri             string
+		httpURL, anyURL bool
+	}{
+		{"https://example.com/a.png", true, true},
+		{"HTTP://example.com/a.png", true, true},
+		{"gs://bucket/a.pdf", false, true},
+		{"data:image/png;base64,AAAA", false, true},
+		{"file-abc123", false, false},
+	}
+	for _, tt := range tests {
+		if got := IsHTTPURL(tt.uri); got != tt.httpURL {
+			t.Errorf("IsHTTPURL(%q): expected %v", tt.uri, tt.httpURL)
+		}
+		if got := IsURL(tt.uri); got != tt.anyURL {
+			t.Errorf("IsURL(%q): expected %v", tt.uri, tt.anyURL)
+		}
+	}
+}
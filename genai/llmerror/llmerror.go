@@ -0,0 +1,174 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package llmerror defines a provider-agnostic taxonomy of LLM errors. The
+// genai/anthropic and genai/openai clients classify their SDK errors into it,
+// so callers can handle them with errors.Is without importing either SDK.
+//
+// ErrContentFiltered only covers requests rejected with an error by OpenAI or
+// Azure OpenAI content filters. Responses filtered or refused after a
+// successful request (OpenAI's content_filter finish reason, Anthropic's
+// refusal stop reason) are not errors: they are returned with
+// genai.FinishReasonSafety.
+package llmerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRateLimited           = errors.New("rate limited")
+	ErrOverloaded            = errors.New("provider overloaded")
+	ErrServer                = errors.New("provider server error")
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrAuthentication        = errors.New("authentication failed")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrContentFiltered       = errors.New("content filtered")
+	ErrTimeout               = errors.New("request timed out")
+)
+
+// Error is a provider error classified into the taxonomy. errors.Is matches
+// both its Kind and the original error, and errors.As reaches the SDK error.
+type Error struct {
+	// Kind is one of the Err* sentinels of this package.
+	Kind error
+	// Provider is the client that returned the error (e.g., "anthropic", "openai").
+	Provider string
+	// StatusCode is the HTTP status of the response, or 0 if there was none.
+	StatusCode int
+	// RequestID is the provider's ID of the failed request, if known.
+	RequestID string
+	// RetryAfter is the wait requested by the provider before retrying, or 0.
+	RetryAfter time.Duration
+	// Err is the original error.
+	Err error
+}
+
+// Error returns the provider, the kind and the original error message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v: %v", e.Provider, e.Kind, e.Err)
+}
+
+// Unwrap returns the kind and the original error.
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable reports whether the request may succeed if sent again: rate
+// limits, overloaded or failing servers and timeouts.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case ErrRateLimited, ErrOverloaded, ErrServer, ErrTimeout:
+		return true
+	}
+	return false
+}
+
+// New classifies an error returned with an HTTP response by its status code,
+// and reads the retry hint from its headers. Clients refine Kind from the
+// provider's error body. It returns nil for statuses that are not errors.
+func New(provider string, resp *http.Response, requestID string, err error) *Error {
+	if resp == nil {
+		return nil
+	}
+	kind := KindFromStatus(resp.StatusCode)
+	if kind == nil {
+		return nil
+	}
+	retryAfter, _ := ParseRetryAfter(resp.Header)
+	return &Error{
+		Kind:       kind,
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RequestID:  requestID,
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+// KindFromStatus returns the kind of an HTTP error status, or nil if it
+// matches none.
+func KindFromStatus(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusServiceUnavailable || status == 529:
+		return ErrOverloaded
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuthentication
+	case status >= 500:
+		return ErrServer
+	case status >= 400:
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// ParseRetryAfter returns the wait requested in the retry-after-ms or
+// Retry-After header (seconds or HTTP date), if any.
+func ParseRetryAfter(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After-Ms"); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return time.Until(date), true
+		}
+	}
+	return 0, false
+}
+
+// RetryAfter returns the retry hint of a classified error, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var llmErr *Error
+	if errors.As(err, &llmErr) && llmErr.RetryAfter > 0 {
+		return llmErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// RequestID returns the provider request ID of a classified error, if any.
+func RequestID(err error) string {
+	var llmErr *Error
+	if errors.As(err, &llmErr) {
+		return llmErr.RequestID
+	}
+	return ""
+}
+
+// IsRetryable reports whether err is a classified error that may succeed if
+// the request is sent again.
+func IsRetryable(err error) bool {
+	var llmErr *Error
+	return errors.As(err, &llmErr) && llmErr.Retryable()
+}
+
+// IsTimeout reports whether err is a deadline or network timeout.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
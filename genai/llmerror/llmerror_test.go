@@ -0,0 +1,104 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	original := errors.New("POST /v1/messages: 429 Too Many Requests")
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3"}}}
+	err := fmt.Errorf("failed: %w", New("anthropic", resp, "req_1", original))
+
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, original) {
+		t.Errorf("Expected the kind and the original error to match, got %v", err)
+	}
+	if wait, ok := RetryAfter(err); !ok || wait != 3*time.Second {
+		t.Errorf("Expected a 3s retry hint, got %v, %v", wait, ok)
+	}
+	if RequestID(err) != "req_1" || !IsRetryable(err) {
+		t.Errorf("Expected a retryable error with request ID, got %v", err)
+	}
+
+	if New("anthropic", &http.Response{StatusCode: http.StatusOK}, "", original) != nil {
+		t.Error("Expected no error for a successful status")
+	}
+	if IsRetryable(original) {
+		t.Error("Expected an unclassified error not to be retryable")
+	}
+}
+
+func TestKindFromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{529, ErrOverloaded},
+		{http.StatusServiceUnavailable, ErrOverloaded},
+		{http.StatusGatewayTimeout, ErrTimeout},
+		{http.StatusForbidden, ErrAuthentication},
+		{http.StatusInternalServerError, ErrServer},
+		{http.StatusNotFound, ErrInvalidRequest},
+		{http.StatusOK, nil},
+	}
+	for _, tt := range tests {
+		if got := KindFromStatus(tt.status); got != tt.want {
+			t.Errorf("KindFromStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		{"milliseconds", http.Header{"Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond, true},
+		{"seconds", http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{"milliseconds first", http.Header{"Retry-After-Ms": {"100"}, "Retry-After": {"2"}}, 100 * time.Millisecond, true},
+		{"none", http.Header{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.header)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Expected %v, %v, got %v, %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got, ok := ParseRetryAfter(http.Header{"Retry-After": {date}}); !ok || got < 59*time.Minute {
+		t.Errorf("Expected about an hour from an HTTP date, got %v, %v", got, ok)
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	if !IsTimeout(fmt.Errorf("request: %w", context.DeadlineExceeded)) {
+		t.Error("Expected a deadline to be a timeout")
+	}
+	if IsTimeout(context.Canceled) {
+		t.Error("Expected a cancellation not to be a timeout")
+	}
+}
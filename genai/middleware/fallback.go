@@ -0,0 +1,187 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"google.golang.org/adk/model"
+)
+
+// FallbackModelKey is the CustomMetadata key holding the name of the model
+// that produced a response of a Fallback model.
+const FallbackModelKey = "fallback_model"
+
+var ErrNoModels = errors.New("no models to fall back to")
+
+// errFirstResponseTimeout cancels a model that did not respond in time.
+var errFirstResponseTimeout = errors.New("no response")
+
+// DefaultFallbackClasses are the error classes that move to the next model
+// when FallbackConfig.Classes is nil.
+var DefaultFallbackClasses = []ErrorClass{
+	ErrorClassRateLimited,
+	ErrorClassOverloaded,
+	ErrorClassServer,
+	ErrorClassTimeout,
+	ErrorClassNetwork,
+}
+
+// FallbackConfig holds configuration for FallbackWithConfig.
+type FallbackConfig struct {
+	// Classes are the error classes that move to the next model. Other
+	// errors are returned immediately. Defaults to DefaultFallbackClasses.
+	Classes []ErrorClass
+	// Timeout is how long each model has to yield its first response before
+	// the next one is tried, whatever Classes holds. Zero disables it. Once a
+	// model has responded, the timeout no longer applies.
+	Timeout time.Duration
+	// Classify maps an error to its class. Defaults to ClassifyError.
+	Classify func(err error) ErrorClass
+	// OnFallback, if set, is called when a model fails and the next one is tried.
+	OnFallback func(failed model.LLM, err error)
+}
+
+// fallbackModel tries an ordered list of LLMs.
+type fallbackModel struct {
+	models     []model.LLM
+	classes    []ErrorClass
+	timeout    time.Duration
+	classify   func(err error) ErrorClass
+	onFallback func(failed model.LLM, err error)
+}
+
+// Fallback returns an LLM that tries the models in order, moving to the next
+// one on the errors of DefaultFallbackClasses. See FallbackWithConfig.
+func Fallback(models ...model.LLM) model.LLM {
+	return FallbackWithConfig(FallbackConfig{}, models...)
+}
+
+// FallbackWithConfig returns an LLM that tries the models in order, e.g. an
+// Anthropic model, then an OpenAI one, then a local one. A model is skipped
+// when it fails with one of the configured error classes, or does not respond
+// within the timeout, before yielding any response: once streaming output has
+// been yielded, errors are passed through. Every response carries the name of
+// the model that produced it in CustomMetadata under FallbackModelKey.
+func FallbackWithConfig(cfg FallbackConfig, models ...model.LLM) model.LLM {
+	classes := cfg.Classes
+	if classes == nil {
+		classes = DefaultFallbackClasses
+	}
+	classify := cfg.Classify
+	if classify == nil {
+		classify = ClassifyError
+	}
+
+	return &fallbackModel{
+		models:     models,
+		classes:    classes,
+		timeout:    cfg.Timeout,
+		classify:   classify,
+		onFallback: cfg.OnFallback,
+	}
+}
+
+// Name returns the name of the first model.
+func (f *fallbackModel) Name() string {
+	if len(f.models) == 0 {
+		return ""
+	}
+	return f.models[0].Name()
+}
+
+// GenerateContent tries each model until one succeeds or fails with an
+// error that does not allow falling back.
+func (f *fallbackModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if len(f.models) == 0 {
+			yield(nil, ErrNoModels)
+			return
+		}
+
+		var errs []error
+		for i, llm := range f.models {
+			failure, done := f.generate(ctx, llm, req, stream, yield)
+			if done {
+				return
+			}
+
+			errs = append(errs, fmt.Errorf("model %s: %w", llm.Name(), failure))
+			last := i == len(f.models)-1
+			if last || ctx.Err() != nil || !f.canFallback(failure) {
+				yield(nil, errors.Join(errs...))
+				return
+			}
+			if f.onFallback != nil {
+				f.onFallback(llm, failure)
+			}
+		}
+	}
+}
+
+// generate runs one model, yielding its responses. It returns the error that
+// failed the model before any response was yielded, or done if the model
+// succeeded, yielded a response, or the consumer stopped.
+func (f *fallbackModel) generate(ctx context.Context, llm model.LLM, req *model.LLMRequest, stream bool, yield func(*model.LLMResponse, error) bool) (failure error, done bool) {
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var timer *time.Timer
+	if f.timeout > 0 {
+		timer = time.AfterFunc(f.timeout, func() { cancel(errFirstResponseTimeout) })
+		defer timer.Stop()
+	}
+
+	yielded := false
+	for resp, err := range llm.GenerateContent(attemptCtx, req, stream) {
+		if !yielded {
+			// Stop fails when the timeout fired, even if a response made it
+			// through: the attempt context is cancelled either way
+			if timer != nil && !timer.Stop() {
+				return fmt.Errorf("%w: %w within %s", llmerror.ErrTimeout, errFirstResponseTimeout, f.timeout), false
+			}
+			if err != nil {
+				return err, false
+			}
+		}
+		yielded = true
+
+		if resp != nil {
+			if resp.CustomMetadata == nil {
+				resp.CustomMetadata = make(map[string]any)
+			}
+			resp.CustomMetadata[FallbackModelKey] = llm.Name()
+		}
+		if !yield(resp, err) {
+			return nil, true
+		}
+	}
+	return nil, true
+}
+
+// canFallback reports whether a failure moves to the next model.
+func (f *fallbackModel) canFallback(err error) bool {
+	if errors.Is(err, errFirstResponseTimeout) {
+		return true
+	}
+	class := f.classify(err)
+	return class != ErrorClassNone && slices.Contains(f.classes, class)
+}
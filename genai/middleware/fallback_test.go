@@ -0,0 +1,140 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"google.golang.org/adk/model"
+)
+
+// hangingLLM blocks until its context is done, then yields the context error.
+type hangingLLM struct{}
+
+func (hangingLLM) Name() string {
+	return "hanging"
+}
+
+func (hangingLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		<-ctx.Done()
+		yield(nil, ctx.Err())
+	}
+}
+
+// lateLLM ignores its context and responds after a delay.
+type lateLLM struct {
+	delay time.Duration
+}
+
+func (lateLLM) Name() string {
+	return "late"
+}
+
+func (l lateLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		time.Sleep(l.delay)
+		if yield(textResponse("Hel", true), nil) {
+			yield(nil, ctx.Err())
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	primary := &fakeLLM{name: "claude", attempts: []fakeAttempt{{err: providerError(llmerror.ErrOverloaded, 0)}}}
+	secondary := &fakeLLM{name: "gpt", attempts: []fakeAttempt{{err: providerError(llmerror.ErrRateLimited, 0)}}}
+	local := &fakeLLM{name: "llama", attempts: []fakeAttempt{{responses: []*model.LLMResponse{textResponse("Hello", false)}}}}
+
+	var failed []string
+	llm := FallbackWithConfig(FallbackConfig{
+		OnFallback: func(m model.LLM, err error) { failed = append(failed, m.Name()) },
+	}, primary, secondary, local)
+
+	responses, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+	if len(responses) != 1 || responses[0].CustomMetadata[FallbackModelKey] != "llama" {
+		t.Errorf("Expected a response from llama, got %v", responses)
+	}
+	if len(failed) != 2 || failed[0] != "claude" || failed[1] != "gpt" {
+		t.Errorf("Expected claude and gpt to fail over, got %v", failed)
+	}
+	if llm.Name() != "claude" {
+		t.Errorf("Expected the first model name, got %q", llm.Name())
+	}
+}
+
+func TestFallbackStops(t *testing.T) {
+	// Errors outside the configured classes are returned
+	primary := &fakeLLM{name: "claude", attempts: []fakeAttempt{{err: providerError(llmerror.ErrContextLengthExceeded, 0)}}}
+	secondary := &fakeLLM{name: "gpt", attempts: []fakeAttempt{{responses: []*model.LLMResponse{textResponse("Hello", false)}}}}
+	_, err := drain(Fallback(primary, secondary).GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if !errors.Is(err, llmerror.ErrContextLengthExceeded) || secondary.calls != 0 {
+		t.Errorf("Expected the error without fallback, got %v after %d calls", err, secondary.calls)
+	}
+
+	// Errors after streaming output are passed through
+	failure := providerError(llmerror.ErrOverloaded, 0)
+	primary = &fakeLLM{name: "claude", attempts: []fakeAttempt{{responses: []*model.LLMResponse{textResponse("Hel", true)}, err: failure}}}
+	responses, err := drain(Fallback(primary, secondary).GenerateContent(context.Background(), &model.LLMRequest{}, true))
+	if !errors.Is(err, failure) || len(responses) != 1 || secondary.calls != 0 {
+		t.Errorf("Expected the mid-stream error without fallback, got %v, %v after %d calls", responses, err, secondary.calls)
+	}
+
+	// When every model fails, all errors are returned
+	primary = &fakeLLM{name: "claude", attempts: []fakeAttempt{{err: providerError(llmerror.ErrOverloaded, 0)}}}
+	secondary = &fakeLLM{name: "gpt", attempts: []fakeAttempt{{err: providerError(llmerror.ErrServer, 0)}}}
+	_, err = drain(Fallback(primary, secondary).GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if !errors.Is(err, llmerror.ErrOverloaded) || !errors.Is(err, llmerror.ErrServer) {
+		t.Errorf("Expected both errors, got %v", err)
+	}
+
+	if _, err := drain(Fallback().GenerateContent(context.Background(), &model.LLMRequest{}, false)); !errors.Is(err, ErrNoModels) {
+		t.Errorf("Expected ErrNoModels, got %v", err)
+	}
+}
+
+func TestFallbackTimeout(t *testing.T) {
+	secondary := &fakeLLM{name: "gpt", attempts: []fakeAttempt{{responses: []*model.LLMResponse{textResponse("Hello", false)}}}}
+	llm := FallbackWithConfig(FallbackConfig{Classes: []ErrorClass{}, Timeout: 10 * time.Millisecond}, hangingLLM{}, secondary)
+
+	responses, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, true))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+	if len(responses) != 1 || responses[0].CustomMetadata[FallbackModelKey] != "gpt" {
+		t.Errorf("Expected a response from gpt after the timeout, got %v", responses)
+	}
+}
+
+func TestFallbackTimeoutRace(t *testing.T) {
+	// A response arriving after the timeout fired is dropped for the next model
+	secondary := &fakeLLM{name: "gpt", attempts: []fakeAttempt{{responses: []*model.LLMResponse{textResponse("Hello", false)}}}}
+	llm := FallbackWithConfig(FallbackConfig{Classes: []ErrorClass{}, Timeout: time.Millisecond}, lateLLM{delay: 20 * time.Millisecond}, secondary)
+
+	responses, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, true))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+	if len(responses) != 1 || responses[0].CustomMetadata[FallbackModelKey] != "gpt" {
+		t.Errorf("Expected a response from gpt after the timeout, got %v", responses)
+	}
+}
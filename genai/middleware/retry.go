@@ -0,0 +1,238 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package middleware provides model.LLM wrappers that add provider-agnostic
+// behavior, such as retries and fallback chains, around any LLM implementation.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ErrorClass groups errors that share a retry policy.
+type ErrorClass string
+
+const (
+	// ErrorClassNone marks errors that are never retried.
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassRateLimited is llmerror.ErrRateLimited, e.g. an HTTP 429 response.
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassOverloaded is llmerror.ErrOverloaded, e.g. an HTTP 529 or 503 response.
+	ErrorClassOverloaded ErrorClass = "overloaded"
+	// ErrorClassServer is llmerror.ErrServer, any other HTTP 5xx response.
+	ErrorClassServer ErrorClass = "server"
+	// ErrorClassTimeout is llmerror.ErrTimeout, a request or response timeout.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassNetwork is another network error, e.g. a reset connection.
+	ErrorClassNetwork ErrorClass = "network"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	// maxRetryAfter is the longest Retry-After hint waited for; longer hints
+	// stop retrying.
+	maxRetryAfter = time.Minute
+)
+
+// DefaultRetryBudgets are the retries allowed per error class when
+// RetryConfig.Budgets is nil.
+var DefaultRetryBudgets = map[ErrorClass]int{
+	ErrorClassRateLimited: 3,
+	ErrorClassOverloaded:  3,
+	ErrorClassServer:      2,
+	ErrorClassTimeout:     1,
+	ErrorClassNetwork:     2,
+}
+
+// RetryConfig holds configuration for Retry.
+type RetryConfig struct {
+	// Budgets is the number of retries allowed per error class within one
+	// GenerateContent call. Classes not listed are not retried. Defaults to
+	// DefaultRetryBudgets.
+	Budgets map[ErrorClass]int
+	// InitialBackoff is the wait before the first retry. It doubles on every
+	// retry, with jitter. Defaults to 500ms.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the computed wait between retries. Defaults to 30s.
+	MaxBackoff time.Duration
+	// Classify maps an error to its class. Defaults to ClassifyError.
+	Classify func(err error) ErrorClass
+	// OnRetry, if set, is called before waiting for each retry.
+	OnRetry func(attempt int, class ErrorClass, wait time.Duration, err error)
+}
+
+// retryModel wraps an LLM and retries failed requests.
+type retryModel struct {
+	inner          model.LLM
+	budgets        map[ErrorClass]int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	classify       func(err error) ErrorClass
+	onRetry        func(attempt int, class ErrorClass, wait time.Duration, err error)
+}
+
+// Retry wraps an LLM so failed requests are retried with jittered exponential
+// backoff, honoring the Retry-After and retry-after-ms headers of the
+// provider: a hint over a minute, or past the context deadline, returns the
+// error instead. Streaming requests are only retried when the failure happens
+// before the first response has been yielded; later errors are passed through.
+//
+// The default ClassifyError understands the errors of the genai/anthropic and
+// genai/openai clients and genai.APIError; other LLM implementations may need
+// RetryConfig.Classify. Both clients also retry on their own through the
+// provider SDK (2 retries by default), which compounds with Retry: set
+// DisableSDKRetries in their Config when wrapping them.
+func Retry(inner model.LLM, cfg RetryConfig) model.LLM {
+	budgets := cfg.Budgets
+	if budgets == nil {
+		budgets = DefaultRetryBudgets
+	}
+	initialBackoff := cfg.InitialBackoff
+	if initialBackoff <= 0 {
+		initialBackoff = defaultInitialBackoff
+	}
+	maxBackoff := cfg.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	classify := cfg.Classify
+	if classify == nil {
+		classify = ClassifyError
+	}
+
+	return &retryModel{
+		inner:          inner,
+		budgets:        budgets,
+		initialBackoff: initialBackoff,
+		maxBackoff:     max(maxBackoff, initialBackoff),
+		classify:       classify,
+		onRetry:        cfg.OnRetry,
+	}
+}
+
+// Name returns the name of the wrapped model.
+func (r *retryModel) Name() string {
+	return r.inner.Name()
+}
+
+// GenerateContent calls the wrapped model, retrying retryable failures that
+// happen before any response is yielded.
+func (r *retryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		retries := make(map[ErrorClass]int)
+		for attempt := 1; ; attempt++ {
+			failure, yielded := error(nil), false
+			for resp, err := range r.inner.GenerateContent(ctx, req, stream) {
+				if err != nil && !yielded {
+					failure = err
+					break
+				}
+				yielded = true
+				if !yield(resp, err) {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+
+			class := r.classify(failure)
+			if class == ErrorClassNone || retries[class] >= r.budgets[class] || ctx.Err() != nil {
+				yield(nil, failure)
+				return
+			}
+			retries[class]++
+
+			wait, ok := r.backoff(ctx, attempt, failure)
+			if !ok {
+				yield(nil, failure)
+				return
+			}
+			if r.onRetry != nil {
+				r.onRetry(attempt, class, wait, failure)
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				yield(nil, failure)
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// backoff returns the wait before the given retry: the provider's Retry-After
+// hint if any, or an exponential backoff with up to 25% jitter. It returns
+// false when the hint is longer than maxRetryAfter or ends after the context
+// deadline, as any earlier retry would be rejected again.
+func (r *retryModel) backoff(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if wait, ok := llmerror.RetryAfter(err); ok {
+		if deadline, ok := ctx.Deadline(); wait > maxRetryAfter || ok && time.Until(deadline) < wait {
+			return 0, false
+		}
+		return wait, true
+	}
+
+	wait := r.initialBackoff
+	for i := 1; i < attempt && wait < r.maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, r.maxBackoff)
+	return wait - time.Duration(rand.Float64()*0.25*float64(wait)), true
+}
+
+// ClassifyError returns the error class of an error classified by the
+// genai/anthropic and genai/openai clients (see package llmerror) or of a
+// genai.APIError by its status code, or ErrorClassNetwork for other network
+// errors. Context cancellation and other errors are ErrorClassNone.
+func ClassifyError(err error) ErrorClass {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		if kind := llmerror.KindFromStatus(apiErr.Code); kind != nil {
+			err = kind
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassNone
+	case errors.Is(err, llmerror.ErrRateLimited):
+		return ErrorClassRateLimited
+	case errors.Is(err, llmerror.ErrOverloaded):
+		return ErrorClassOverloaded
+	case errors.Is(err, llmerror.ErrServer):
+		return ErrorClassServer
+	case errors.Is(err, llmerror.ErrTimeout):
+		return ErrorClassTimeout
+	}
+
+	var llmErr *llmerror.Error
+	var netErr net.Error
+	if !errors.As(err, &llmErr) && errors.As(err, &netErr) {
+		return ErrorClassNetwork
+	}
+	return ErrorClassNone
+}
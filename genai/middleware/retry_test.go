@@ -0,0 +1,234 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// fakeLLM replays one scripted attempt per GenerateContent call. Each
+// attempt yields its responses, then its error if not nil.
+type fakeLLM struct {
+	name     string
+	attempts []fakeAttempt
+	calls    int
+}
+
+type fakeAttempt struct {
+	responses []*model.LLMResponse
+	err       error
+}
+
+func (f *fakeLLM) Name() string {
+	return f.name
+}
+
+func (f *fakeLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	attempt := f.attempts[min(f.calls, len(f.attempts)-1)]
+	f.calls++
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, resp := range attempt.responses {
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if attempt.err != nil {
+			yield(nil, attempt.err)
+		}
+	}
+}
+
+// textResponse builds a response with a single text part.
+func textResponse(text string, partial bool) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content: genai.NewContentFromText(text, genai.RoleModel),
+		Partial: partial,
+	}
+}
+
+// providerError builds a classified provider error.
+func providerError(kind error, retryAfter time.Duration) error {
+	return &llmerror.Error{Kind: kind, Provider: "fake", RetryAfter: retryAfter, Err: errors.New("boom")}
+}
+
+// drain collects the responses and the last error of an iterator.
+func drain(seq iter.Seq2[*model.LLMResponse, error]) ([]*model.LLMResponse, error) {
+	var responses []*model.LLMResponse
+	var lastErr error
+	for resp, err := range seq {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	return responses, lastErr
+}
+
+func TestRetry(t *testing.T) {
+	inner := &fakeLLM{name: "fake", attempts: []fakeAttempt{
+		{err: providerError(llmerror.ErrOverloaded, 0)},
+		{err: providerError(llmerror.ErrRateLimited, time.Millisecond)},
+		{responses: []*model.LLMResponse{textResponse("Hello", false)}},
+	}}
+	var classes []ErrorClass
+	llm := Retry(inner, RetryConfig{
+		InitialBackoff: time.Millisecond,
+		OnRetry: func(attempt int, class ErrorClass, wait time.Duration, err error) {
+			classes = append(classes, class)
+		},
+	})
+
+	responses, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+	if len(responses) != 1 || responses[0].Content.Parts[0].Text != "Hello" {
+		t.Errorf("Expected the successful response, got %v", responses)
+	}
+	if inner.calls != 3 || len(classes) != 2 || classes[0] != ErrorClassOverloaded || classes[1] != ErrorClassRateLimited {
+		t.Errorf("Expected retries on overloaded and rate limited, got %d calls and %v", inner.calls, classes)
+	}
+	if llm.Name() != "fake" {
+		t.Errorf("Expected the inner model name, got %q", llm.Name())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	inner := &fakeLLM{attempts: []fakeAttempt{
+		{err: providerError(llmerror.ErrRateLimited, 7*time.Millisecond)},
+		{responses: []*model.LLMResponse{textResponse("Hello", false)}},
+	}}
+	var waits []time.Duration
+	llm := Retry(inner, RetryConfig{
+		InitialBackoff: time.Second,
+		OnRetry: func(attempt int, class ErrorClass, wait time.Duration, err error) {
+			waits = append(waits, wait)
+		},
+	})
+
+	if _, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, false)); err != nil {
+		t.Fatalf("GenerateContent failed: %v", err)
+	}
+	if len(waits) != 1 || waits[0] != 7*time.Millisecond {
+		t.Errorf("Expected the Retry-After hint to be waited, got %v", waits)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	failure := providerError(llmerror.ErrRateLimited, 2*time.Minute)
+	inner := &fakeLLM{attempts: []fakeAttempt{{err: failure}}}
+	_, err := drain(Retry(inner, RetryConfig{InitialBackoff: time.Millisecond}).GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if !errors.Is(err, failure) || inner.calls != 1 {
+		t.Errorf("Expected the error without retrying, got %v after %d calls", err, inner.calls)
+	}
+
+	// A hint past the context deadline is not waited for either
+	failure = providerError(llmerror.ErrRateLimited, 30*time.Second)
+	inner = &fakeLLM{attempts: []fakeAttempt{{err: failure}}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = drain(Retry(inner, RetryConfig{}).GenerateContent(ctx, &model.LLMRequest{}, false))
+	if !errors.Is(err, failure) || inner.calls != 1 || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected the error without waiting, got %v after %d calls", err, inner.calls)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	failure := providerError(llmerror.ErrServer, 0)
+	inner := &fakeLLM{attempts: []fakeAttempt{{err: failure}}}
+	llm := Retry(inner, RetryConfig{
+		Budgets:        map[ErrorClass]int{ErrorClassServer: 1},
+		InitialBackoff: time.Millisecond,
+	})
+
+	if _, err := drain(llm.GenerateContent(context.Background(), &model.LLMRequest{}, false)); !errors.Is(err, failure) {
+		t.Errorf("Expected the last error once the budget is spent, got %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Expected 1 retry, got %d calls", inner.calls)
+	}
+
+	inner = &fakeLLM{attempts: []fakeAttempt{{err: providerError(llmerror.ErrInvalidRequest, 0)}}}
+	drain(Retry(inner, RetryConfig{}).GenerateContent(context.Background(), &model.LLMRequest{}, false))
+	if inner.calls != 1 {
+		t.Errorf("Expected no retry for a bad request, got %d calls", inner.calls)
+	}
+}
+
+func TestRetryStream(t *testing.T) {
+	// Failures before the first partial are retried
+	inner := &fakeLLM{attempts: []fakeAttempt{
+		{err: providerError(llmerror.ErrOverloaded, 0)},
+		{responses: []*model.LLMResponse{textResponse("Hel", true), textResponse("Hello", false)}},
+	}}
+	responses, err := drain(Retry(inner, RetryConfig{InitialBackoff: time.Millisecond}).GenerateContent(context.Background(), &model.LLMRequest{}, true))
+	if err != nil || len(responses) != 2 || inner.calls != 2 {
+		t.Errorf("Expected a retried stream, got %v, %v after %d calls", responses, err, inner.calls)
+	}
+
+	// Failures after the first partial are passed through
+	failure := providerError(llmerror.ErrOverloaded, 0)
+	inner = &fakeLLM{attempts: []fakeAttempt{
+		{responses: []*model.LLMResponse{textResponse("Hel", true)}, err: failure},
+	}}
+	responses, err = drain(Retry(inner, RetryConfig{InitialBackoff: time.Millisecond}).GenerateContent(context.Background(), &model.LLMRequest{}, true))
+	if !errors.Is(err, failure) || len(responses) != 1 || inner.calls != 1 {
+		t.Errorf("Expected the mid-stream error without retry, got %v, %v after %d calls", responses, err, inner.calls)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{providerError(llmerror.ErrRateLimited, 0), ErrorClassRateLimited},
+		{providerError(llmerror.ErrOverloaded, 0), ErrorClassOverloaded},
+		{fmt.Errorf("wrapped: %w", providerError(llmerror.ErrServer, 0)), ErrorClassServer},
+		{providerError(llmerror.ErrTimeout, 0), ErrorClassTimeout},
+		{providerError(llmerror.ErrAuthentication, 0), ErrorClassNone},
+		{providerError(llmerror.ErrContextLengthExceeded, 0), ErrorClassNone},
+		{genai.APIError{Code: 429, Message: "quota exceeded"}, ErrorClassRateLimited},
+		{fmt.Errorf("wrapped: %w", genai.APIError{Code: 503}), ErrorClassOverloaded},
+		{genai.APIError{Code: 400, Message: "bad request"}, ErrorClassNone},
+		{&url.Error{Op: "Post", URL: "https://api.anthropic.com", Err: &timeoutError{}}, ErrorClassNetwork},
+		{context.Canceled, ErrorClassNone},
+		{errors.New("boom"), ErrorClassNone},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// timeoutError is a net.Error reporting a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
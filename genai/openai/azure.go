@@ -0,0 +1,48 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"net/http"
+
+	"github.com/achetronic/adk-utils-go/internal/azure"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI api-version used when
+// AzureConfig.APIVersion is empty.
+const DefaultAzureAPIVersion = azure.DefaultAPIVersion
+
+// AzureConfig configures access to an Azure OpenAI deployment: Endpoint,
+// Deployment, APIVersion, and either APIKey or a TokenProvider returning
+// Microsoft Entra ID tokens. Set it on Config.Azure; memory/postgres accepts
+// the same type.
+type AzureConfig = azure.Config
+
+// azureRequestOptions returns the OpenAI client options targeting the deployment.
+// The Bearer header set from OPENAI_API_KEY is dropped in favor of Authorize.
+func azureRequestOptions(c *AzureConfig) []option.RequestOption {
+	return []option.RequestOption{
+		option.WithBaseURL(c.BaseURL() + "/"),
+		option.WithQuery("api-version", c.Version()),
+		option.WithHeaderDel("Authorization"),
+		option.WithMiddleware(func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+			if err := c.Authorize(req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}),
+	}
+}
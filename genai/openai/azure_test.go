@@ -0,0 +1,89 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAzure(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+
+	var gotReq *http.Request
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-1",
+			"object":  "chat.completion",
+			"model":   "gpt-4o",
+			"choices": []any{map[string]any{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": "Hi"}}},
+		})
+	})
+
+	azure := &AzureConfig{Endpoint: server.URL, Deployment: "gpt-4o-prod", APIKey: "azure-key"}
+	m := New(Config{Azure: azure})
+	collect(t, m, userRequest("hello"), false)
+
+	if m.Name() != "gpt-4o-prod" {
+		t.Errorf("Expected model name to default to the deployment, got %q", m.Name())
+	}
+	if gotReq.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("Expected deployment path, got %s", gotReq.URL.Path)
+	}
+	if v := gotReq.URL.Query().Get("api-version"); v != DefaultAzureAPIVersion {
+		t.Errorf("Expected default api-version, got %q", v)
+	}
+	if gotReq.Header.Get("Api-Key") != "azure-key" || gotReq.Header.Get("Authorization") != "" {
+		t.Errorf("Expected api-key auth only, got api-key=%q authorization=%q", gotReq.Header.Get("Api-Key"), gotReq.Header.Get("Authorization"))
+	}
+
+	// Entra ID tokens replace the API key
+	azure.TokenProvider = func(ctx context.Context) (string, error) { return "entra-token", nil }
+	m = New(Config{Azure: azure})
+	collect(t, m, userRequest("hello"), false)
+	if gotReq.Header.Get("Authorization") != "Bearer entra-token" || gotReq.Header.Get("Api-Key") != "" {
+		t.Errorf("Expected Bearer token auth, got authorization=%q api-key=%q", gotReq.Header.Get("Authorization"), gotReq.Header.Get("Api-Key"))
+	}
+
+	// Token failures are returned as errors
+	errToken := errors.New("no credentials")
+	azure.TokenProvider = func(ctx context.Context) (string, error) { return "", errToken }
+	m = New(Config{Azure: azure, DisableSDKRetries: true})
+	for _, err := range m.GenerateContent(context.Background(), userRequest("hello"), false) {
+		if !errors.Is(err, errToken) {
+			t.Errorf("Expected token error, got %v", err)
+		}
+	}
+
+	// The Responses API is not served by deployments
+	gotReq = nil
+	m = New(Config{Azure: azure, API: APIResponses})
+	for _, err := range m.GenerateContent(context.Background(), userRequest("hello"), true) {
+		if !errors.Is(err, ErrAPIUnsupported) {
+			t.Errorf("Expected ErrAPIUnsupported, got %v", err)
+		}
+	}
+	if _, err := m.CountTokens(context.Background(), userRequest("hello")); !errors.Is(err, ErrAPIUnsupported) {
+		t.Errorf("Expected ErrAPIUnsupported from CountTokens, got %v", err)
+	}
+	if gotReq != nil {
+		t.Errorf("Expected no request, got %s", gotReq.URL.Path)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/adk/model"
+)
+
+const (
+	defaultBatchPollInterval    = 10 * time.Second
+	defaultBatchMaxPollInterval = 5 * time.Minute
+)
+
+// BatchConfig holds configuration for creating a new BatchSubmitter.
+type BatchConfig struct {
+	// PollInterval is the wait before the first status check of a batch.
+	// It doubles after every check. Defaults to 10 seconds.
+	PollInterval time.Duration
+	// MaxPollInterval caps the wait between status checks. Defaults to 5 minutes.
+	MaxPollInterval time.Duration
+	// OnStatus, if set, is called with the batch after every status check,
+	// e.g. to log its status and request counts.
+	OnStatus func(batch *openai.Batch)
+}
+
+// BatchResult is the outcome of one request of a batch: either a response
+// converted as GenerateContent would return it, or an error.
+type BatchResult struct {
+	Response *model.LLMResponse
+	Err      error
+}
+
+// BatchSubmitter runs many chat completion requests through the Batch API,
+// which processes them asynchronously within 24 hours at a lower price. It
+// suits offline jobs such as bulk classification.
+type BatchSubmitter struct {
+	model           *Model
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	onStatus        func(batch *openai.Batch)
+}
+
+// batchInputLine is a line of a batch input file.
+type batchInputLine struct {
+	CustomID string                         `json:"custom_id"`
+	Method   string                         `json:"method"`
+	URL      string                         `json:"url"`
+	Body     openai.ChatCompletionNewParams `json:"body"`
+}
+
+// batchOutputLine is a line of a batch output or error file.
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		RequestID  string          `json:"request_id"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *batchLineError `json:"error"`
+}
+
+// batchLineError is the error of a failed batch request.
+type batchLineError struct {
+	Code    string `json:"code"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// NewBatchSubmitter creates a BatchSubmitter that builds requests with the
+// settings of the given Model (model name, strict schemas, tool call IDs...).
+func NewBatchSubmitter(m *Model, cfg BatchConfig) *BatchSubmitter {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultBatchPollInterval
+	}
+	maxPollInterval := cfg.MaxPollInterval
+	if maxPollInterval <= 0 {
+		maxPollInterval = defaultBatchMaxPollInterval
+	}
+	maxPollInterval = max(maxPollInterval, pollInterval)
+
+	return &BatchSubmitter{
+		model:           m,
+		pollInterval:    pollInterval,
+		maxPollInterval: maxPollInterval,
+		onStatus:        cfg.OnStatus,
+	}
+}
+
+// Run submits the requests as one batch, waits for it to finish and returns
+// the results keyed by custom ID. See Submit and Wait.
+func (b *BatchSubmitter) Run(ctx context.Context, requests map[string]*model.LLMRequest) (map[string]BatchResult, error) {
+	batchID, err := b.Submit(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	return b.Wait(ctx, batchID)
+}
+
+// Submit converts the requests, keyed by custom ID, into a JSONL input file
+// for /v1/chat/completions, uploads it and creates a batch. It returns the
+// batch ID, to be passed to Wait, possibly from another process. Azure
+// deployments are not supported and return ErrBatchUnsupported.
+func (b *BatchSubmitter) Submit(ctx context.Context, requests map[string]*model.LLMRequest) (string, error) {
+	if b.model.azure {
+		return "", ErrBatchUnsupported
+	}
+	if len(requests) == 0 {
+		return "", fmt.Errorf("failed to create batch: no requests")
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, customID := range slices.Sorted(maps.Keys(requests)) {
+		if customID == "" {
+			return "", fmt.Errorf("invalid custom ID: must not be empty")
+		}
+		params, err := b.model.buildChatCompletionParams(requests[customID])
+		if err != nil {
+			return "", fmt.Errorf("failed to build request %q: %w", customID, err)
+		}
+		line := batchInputLine{
+			CustomID: customID,
+			Method:   "POST",
+			URL:      string(openai.BatchNewParamsEndpointV1ChatCompletions),
+			Body:     params,
+		}
+		if err := encoder.Encode(line); err != nil {
+			return "", fmt.Errorf("failed to encode request %q: %w", customID, err)
+		}
+	}
+
+	file, err := b.model.client.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&input, "batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload batch input file: %w", classifyError(err))
+	}
+
+	batch, err := b.model.client.Batches.New(ctx, openai.BatchNewParams{
+		InputFileID:      file.ID,
+		Endpoint:         openai.BatchNewParamsEndpointV1ChatCompletions,
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create batch: %w", classifyError(err))
+	}
+	return batch.ID, nil
+}
+
+// Wait polls the batch with exponential backoff until it completes, expires
+// or is cancelled, then parses its output and error files into results keyed
+// by custom ID. Failed requests, including those left unfinished by an
+// expired or cancelled batch, have a BatchResult.Err wrapping
+// ErrBatchRequestFailed, classified into llmerror when the HTTP status or
+// error code is known. A batch that fails validation returns ErrBatchFailed.
+func (b *BatchSubmitter) Wait(ctx context.Context, batchID string) (map[string]BatchResult, error) {
+	if b.model.azure {
+		return nil, ErrBatchUnsupported
+	}
+
+	interval := b.pollInterval
+	for {
+		batch, err := b.model.client.Batches.Get(ctx, batchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get batch %s: %w", batchID, classifyError(err))
+		}
+		if b.onStatus != nil {
+			b.onStatus(batch)
+		}
+
+		switch batch.Status {
+		case openai.BatchStatusFailed:
+			return nil, fmt.Errorf("%w: %s", ErrBatchFailed, batchErrorsMessage(batch.Errors))
+		case openai.BatchStatusCompleted, openai.BatchStatusExpired, openai.BatchStatusCancelled:
+			return b.results(ctx, batch)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		interval = min(interval*2, b.maxPollInterval)
+	}
+}
+
+// results downloads and parses the output and error files of a finished batch.
+func (b *BatchSubmitter) results(ctx context.Context, batch *openai.Batch) (map[string]BatchResult, error) {
+	results := make(map[string]BatchResult)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err := b.readResults(ctx, fileID, results); err != nil {
+			return nil, fmt.Errorf("failed to get batch %s results: %w", batch.ID, classifyError(err))
+		}
+	}
+	return results, nil
+}
+
+// readResults parses a JSONL output or error file into results.
+func (b *BatchSubmitter) readResults(ctx context.Context, fileID string, results map[string]BatchResult) error {
+	resp, err := b.model.client.Files.Content(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line batchOutputLine
+		if err := decoder.Decode(&line); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to parse file %s: %w", fileID, err)
+		}
+		results[line.CustomID] = b.convertBatchResult(line)
+	}
+}
+
+// convertBatchResult converts a line of an output or error file.
+func (b *BatchSubmitter) convertBatchResult(line batchOutputLine) BatchResult {
+	if line.Error != nil {
+		return BatchResult{Err: fmt.Errorf("%w: %s", ErrBatchRequestFailed, line.Error)}
+	}
+	if line.Response == nil {
+		return BatchResult{Err: fmt.Errorf("%w: no response", ErrBatchRequestFailed)}
+	}
+
+	if line.Response.StatusCode < 200 || line.Response.StatusCode >= 300 {
+		var body struct {
+			Error *batchLineError `json:"error"`
+		}
+		json.Unmarshal(line.Response.Body, &body)
+		err := fmt.Errorf("%w: status %d", ErrBatchRequestFailed, line.Response.StatusCode)
+		kind := llmerror.KindFromStatus(line.Response.StatusCode)
+		if body.Error != nil {
+			err = fmt.Errorf("%w: status %d: %s", ErrBatchRequestFailed, line.Response.StatusCode, body.Error)
+			if codeKind := errorCodeKind(body.Error.Code, body.Error.Type); codeKind != nil {
+				kind = codeKind
+			}
+		}
+		if kind != nil {
+			err = &llmerror.Error{
+				Kind:       kind,
+				Provider:   providerName,
+				StatusCode: line.Response.StatusCode,
+				RequestID:  line.Response.RequestID,
+				Err:        err,
+			}
+		}
+		return BatchResult{Err: err}
+	}
+
+	var completion openai.ChatCompletion
+	if err := json.Unmarshal(line.Response.Body, &completion); err != nil {
+		return BatchResult{Err: fmt.Errorf("failed to parse response: %w", err)}
+	}
+	llmResp, err := b.model.convertResponse(&completion)
+	return BatchResult{Response: llmResp, Err: err}
+}
+
+// String formats the error code (or type) and message.
+func (e *batchLineError) String() string {
+	code := e.Code
+	if code == "" {
+		code = e.Type
+	}
+	return code + ": " + e.Message
+}
+
+// batchErrorsMessage joins the validation errors of a failed batch.
+func batchErrorsMessage(batchErrors openai.BatchErrors) string {
+	messages := make([]string, 0, len(batchErrors.Data))
+	for _, batchErr := range batchErrors.Data {
+		message := batchErr.Code + ": " + batchErr.Message
+		if batchErr.Line > 0 {
+			message = fmt.Sprintf("line %d: %s", batchErr.Line, message)
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return "no details"
+	}
+	return strings.Join(messages, "; ")
+}
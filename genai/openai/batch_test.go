@@ -0,0 +1,157 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/adk/model"
+)
+
+// batchObject builds a minimal Batch API object.
+func batchObject(status string) map[string]any {
+	return map[string]any{
+		"id":                "batch_1",
+		"object":            "batch",
+		"endpoint":          "/v1/chat/completions",
+		"input_file_id":     "file-in",
+		"completion_window": "24h",
+		"status":            status,
+		"output_file_id":    "file-out",
+		"error_file_id":     "file-err",
+		"errors":            map[string]any{"data": []any{map[string]any{"code": "invalid_json", "line": 2, "message": "bad line"}}},
+	}
+}
+
+func TestBatchSubmitter(t *testing.T) {
+	var inputLines []map[string]any
+	var batchBody map[string]any
+	polls := 0
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /files":
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("Expected a multipart file upload: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var line map[string]any
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Errorf("Expected a JSON input line: %v", err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				inputLines = append(inputLines, line)
+			}
+			if r.FormValue("purpose") != "batch" {
+				t.Errorf("Expected purpose batch, got %q", r.FormValue("purpose"))
+			}
+			json.NewEncoder(w).Encode(map[string]any{"id": "file-in", "object": "file", "purpose": "batch"})
+		case "POST /batches":
+			json.NewDecoder(r.Body).Decode(&batchBody)
+			json.NewEncoder(w).Encode(batchObject("validating"))
+		case "GET /batches/batch_1":
+			polls++
+			if polls < 3 {
+				json.NewEncoder(w).Encode(batchObject("in_progress"))
+				return
+			}
+			json.NewEncoder(w).Encode(batchObject("completed"))
+		case "GET /files/file-out/content":
+			enc := json.NewEncoder(w)
+			enc.Encode(map[string]any{"custom_id": "label-1", "response": map[string]any{"status_code": 200, "body": map[string]any{
+				"id": "chatcmpl-1", "object": "chat.completion", "model": "test-model",
+				"choices": []any{map[string]any{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": "positive"}}},
+			}}})
+			enc.Encode(map[string]any{"custom_id": "label-2", "response": map[string]any{"status_code": 400, "body": map[string]any{
+				"error": map[string]any{"type": "invalid_request_error", "message": "bad request"},
+			}}})
+		case "GET /files/file-err/content":
+			json.NewEncoder(w).Encode(map[string]any{"custom_id": "label-3", "error": map[string]any{"code": "batch_expired", "message": "expired"}})
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	var statuses []openai.BatchStatus
+	batch := NewBatchSubmitter(m, BatchConfig{
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 2 * time.Millisecond,
+		OnStatus:        func(b *openai.Batch) { statuses = append(statuses, b.Status) },
+	})
+
+	results, err := batch.Run(context.Background(), map[string]*model.LLMRequest{
+		"label-1": userRequest("Classify conversation 1"),
+		"label-2": userRequest("Classify conversation 2"),
+		"label-3": userRequest("Classify conversation 3"),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(inputLines) != 3 {
+		t.Fatalf("Expected 3 input lines, got %v", inputLines)
+	}
+	body := inputLines[0]["body"].(map[string]any)
+	if inputLines[0]["custom_id"] != "label-1" || inputLines[0]["url"] != "/v1/chat/completions" || body["model"] != "test-model" || len(body["messages"].([]any)) != 1 {
+		t.Errorf("Expected a chat completions input line, got %v", inputLines[0])
+	}
+	if batchBody["input_file_id"] != "file-in" || batchBody["endpoint"] != "/v1/chat/completions" || batchBody["completion_window"] != "24h" {
+		t.Errorf("Expected the uploaded file to be batched, got %v", batchBody)
+	}
+	if len(statuses) != 3 || statuses[2] != openai.BatchStatusCompleted {
+		t.Errorf("Expected 3 reported statuses, got %v", statuses)
+	}
+
+	if got := results["label-1"]; got.Err != nil || got.Response.Content.Parts[0].Text != "positive" {
+		t.Errorf("Expected a converted response for label-1, got %+v", got)
+	}
+	for _, id := range []string{"label-2", "label-3"} {
+		if err := results[id].Err; !errors.Is(err, ErrBatchRequestFailed) {
+			t.Errorf("Expected ErrBatchRequestFailed for %s, got %v", id, err)
+		}
+	}
+}
+
+func TestBatchSubmitterFailed(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(batchObject("failed"))
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	if _, err := NewBatchSubmitter(m, BatchConfig{}).Wait(context.Background(), "batch_1"); !errors.Is(err, ErrBatchFailed) {
+		t.Errorf("Expected ErrBatchFailed, got %v", err)
+	}
+
+	azure := New(Config{Azure: &AzureConfig{Endpoint: server.URL, Deployment: "gpt-4o"}})
+	requests := map[string]*model.LLMRequest{"label-1": userRequest("Hi")}
+	if _, err := NewBatchSubmitter(azure, BatchConfig{}).Submit(context.Background(), requests); !errors.Is(err, ErrBatchUnsupported) {
+		t.Errorf("Expected ErrBatchUnsupported on Azure, got %v", err)
+	}
+}
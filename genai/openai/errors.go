@@ -0,0 +1,105 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/openai/openai-go/v3"
+)
+
+// providerName identifies this client in llmerror.Error.
+const providerName = "openai"
+
+// streamErrorPrefix starts the message of errors received as stream events.
+const streamErrorPrefix = "received error while streaming: "
+
+// errorCodeKinds maps OpenAI error codes (and types) to the llmerror taxonomy.
+// Codes take precedence over the HTTP status.
+var errorCodeKinds = map[string]error{
+	"rate_limit_exceeded":      llmerror.ErrRateLimited,
+	"server_error":             llmerror.ErrServer,
+	"context_length_exceeded":  llmerror.ErrContextLengthExceeded,
+	"string_above_max_length":  llmerror.ErrContextLengthExceeded,
+	"invalid_api_key":          llmerror.ErrAuthentication,
+	"content_filter":           llmerror.ErrContentFiltered,
+	"content_policy_violation": llmerror.ErrContentFiltered,
+}
+
+// classifyError wraps SDK errors into an llmerror.Error, from the HTTP
+// status and the error code of the body. Other errors are returned as-is.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var llmErr *llmerror.Error
+	if errors.As(err, &llmErr) {
+		return err
+	}
+
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		var requestID string
+		if apiErr.Response != nil {
+			requestID = apiErr.Response.Header.Get("X-Request-Id")
+		}
+		classified := llmerror.New(providerName, apiErr.Response, requestID, err)
+		if classified == nil {
+			return err
+		}
+		if kind := errorCodeKind(apiErr.Code, apiErr.Type); kind != nil {
+			classified.Kind = kind
+		}
+		return classified
+	}
+
+	// Errors received mid-stream carry the error object as JSON
+	if _, data, ok := strings.Cut(err.Error(), streamErrorPrefix); ok {
+		var body struct {
+			Code string `json:"code"`
+			Type string `json:"type"`
+		}
+		if json.Unmarshal([]byte(data), &body) == nil {
+			if kind := errorCodeKind(body.Code, body.Type); kind != nil {
+				return &llmerror.Error{Kind: kind, Provider: providerName, Err: err}
+			}
+		}
+	}
+
+	if llmerror.IsTimeout(err) {
+		return &llmerror.Error{Kind: llmerror.ErrTimeout, Provider: providerName, Err: err}
+	}
+	return err
+}
+
+// classifyErrorCode wraps an error reported with an OpenAI error code, e.g.
+// by a Responses API event, into an llmerror.Error if the code is known.
+func classifyErrorCode(err error, code string) error {
+	if kind := errorCodeKind(code, ""); kind != nil {
+		return &llmerror.Error{Kind: kind, Provider: providerName, Err: err}
+	}
+	return err
+}
+
+// errorCodeKind returns the kind of an OpenAI error code or type, or nil if unknown.
+func errorCodeKind(code, errType string) error {
+	if kind, ok := errorCodeKinds[code]; ok {
+		return kind
+	}
+	return errorCodeKinds[errType]
+}
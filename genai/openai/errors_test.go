@@ -0,0 +1,75 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/llmerror"
+	"github.com/openai/openai-go/v3"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		code   string
+		want   error
+	}{
+		{"rate limited", http.StatusTooManyRequests, "rate_limit_exceeded", llmerror.ErrRateLimited},
+		{"overloaded", http.StatusServiceUnavailable, "", llmerror.ErrOverloaded},
+		{"context length", http.StatusBadRequest, "context_length_exceeded", llmerror.ErrContextLengthExceeded},
+		{"content filtered", http.StatusBadRequest, "content_filter", llmerror.ErrContentFiltered},
+		{"authentication", http.StatusUnauthorized, "invalid_api_key", llmerror.ErrAuthentication},
+		{"invalid request", http.StatusBadRequest, "", llmerror.ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Request-Id", "req_1")
+				w.Header().Set("Retry-After-Ms", "1")
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"message": "failed", "type": "invalid_request_error", "code": tt.code}})
+			})
+
+			m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model", DisableSDKRetries: true})
+			var err error
+			for _, err = range m.GenerateContent(context.Background(), userRequest("Hi"), false) {
+			}
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			if requests != 1 {
+				t.Errorf("Expected SDK retries to be disabled, got %d requests", requests)
+			}
+			var llmErr *llmerror.Error
+			if !errors.As(err, &llmErr) || llmErr.StatusCode != tt.status || llmErr.RequestID != "req_1" || llmErr.Provider != "openai" {
+				t.Errorf("Expected status, request ID and provider, got %+v", llmErr)
+			}
+			var apiErr *openai.Error
+			if !errors.As(err, &apiErr) {
+				t.Errorf("Expected the SDK error to be wrapped, got %v", err)
+			}
+		})
+	}
+}
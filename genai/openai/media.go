@@ -0,0 +1,162 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/media"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"google.golang.org/genai"
+)
+
+// audioFormats maps audio MIME types to input_audio formats.
+var audioFormats = map[string]string{
+	"audio/wav":   "wav",
+	"audio/wave":  "wav",
+	"audio/x-wav": "wav",
+	"audio/mpeg":  "mp3",
+	"audio/mp3":   "mp3",
+}
+
+// defaultPDFFilename is sent when a PDF part has no display name.
+const defaultPDFFilename = "document.pdf"
+
+// fileDataKind classifies a FileData part from its MIME type, or from the URI
+// extension when the MIME type is missing. Unknown files are assumed to be
+// images, the only kind chat completions accepts by URL.
+func fileDataKind(data *genai.FileData) media.Kind {
+	mimeType := data.MIMEType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(data.FileURI))
+		if mimeType == "" {
+			return media.KindImage
+		}
+	}
+	return media.KindOf(mimeType)
+}
+
+// audioFormat returns the input_audio format of an audio MIME type, or "" if
+// OpenAI does not accept it.
+func audioFormat(mimeType string) string {
+	return audioFormats[media.BaseType(mimeType)]
+}
+
+// dataURL encodes inline data as a base64 data URL.
+func dataURL(data *genai.Blob) string {
+	return fmt.Sprintf("data:%s;base64,%s", data.MIMEType, base64.StdEncoding.EncodeToString(data.Data))
+}
+
+// pdfFilename returns the display name of a PDF part, or a default one.
+func pdfFilename(displayName string) string {
+	if displayName != "" {
+		return displayName
+	}
+	return defaultPDFFilename
+}
+
+// convertMediaPart converts an inline or file data part to a chat completions
+// content part: images as image_url, wav/mp3 audio as input_audio and PDFs as
+// file parts. File URIs are forwarded as URLs (or file IDs for PDFs).
+func convertMediaPart(part *genai.Part) (openai.ChatCompletionContentPartUnionParam, error) {
+	if data := part.InlineData; data != nil {
+		switch media.KindOf(data.MIMEType) {
+		case media.KindImage:
+			return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+				URL:    dataURL(data),
+				Detail: "auto",
+			}), nil
+		case media.KindAudio:
+			if format := audioFormat(data.MIMEType); format != "" {
+				return openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+					Data:   base64.StdEncoding.EncodeToString(data.Data),
+					Format: format,
+				}), nil
+			}
+		case media.KindPDF:
+			return openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+				FileData: openai.String(dataURL(data)),
+				Filename: openai.String(pdfFilename(data.DisplayName)),
+			}), nil
+		}
+		return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("%w: inline data of type %q", ErrUnsupportedPart, data.MIMEType)
+	}
+
+	data := part.FileData
+	switch fileDataKind(data) {
+	case media.KindImage:
+		return openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+			URL:    data.FileURI,
+			Detail: "auto",
+		}), nil
+	case media.KindPDF:
+		// Chat completions only references files uploaded to the Files API
+		if !media.IsURL(data.FileURI) {
+			return openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+				FileID: openai.String(data.FileURI),
+			}), nil
+		}
+	}
+	return openai.ChatCompletionContentPartUnionParam{}, fmt.Errorf("%w: file %q of type %q", ErrUnsupportedPart, data.FileURI, data.MIMEType)
+}
+
+// convertMediaInput converts an inline or file data part to a Responses API
+// input content part. The Responses API has no audio input in messages.
+func convertMediaInput(part *genai.Part) (responses.ResponseInputContentUnionParam, error) {
+	if data := part.InlineData; data != nil {
+		switch media.KindOf(data.MIMEType) {
+		case media.KindImage:
+			return responses.ResponseInputContentUnionParam{
+				OfInputImage: &responses.ResponseInputImageParam{
+					ImageURL: openai.String(dataURL(data)),
+					Detail:   responses.ResponseInputImageDetailAuto,
+				},
+			}, nil
+		case media.KindPDF:
+			return responses.ResponseInputContentUnionParam{
+				OfInputFile: &responses.ResponseInputFileParam{
+					FileData: openai.String(dataURL(data)),
+					Filename: openai.String(pdfFilename(data.DisplayName)),
+				},
+			}, nil
+		}
+		return responses.ResponseInputContentUnionParam{}, fmt.Errorf("%w: inline data of type %q", ErrUnsupportedPart, data.MIMEType)
+	}
+
+	data := part.FileData
+	switch fileDataKind(data) {
+	case media.KindImage:
+		image := &responses.ResponseInputImageParam{Detail: responses.ResponseInputImageDetailAuto}
+		if media.IsURL(data.FileURI) {
+			image.ImageURL = openai.String(data.FileURI)
+		} else {
+			image.FileID = openai.String(data.FileURI)
+		}
+		return responses.ResponseInputContentUnionParam{OfInputImage: image}, nil
+	case media.KindPDF:
+		file := &responses.ResponseInputFileParam{}
+		if media.IsURL(data.FileURI) {
+			file.FileURL = openai.String(data.FileURI)
+		} else {
+			file.FileID = openai.String(data.FileURI)
+		}
+		return responses.ResponseInputContentUnionParam{OfInputFile: file}, nil
+	}
+	return responses.ResponseInputContentUnionParam{}, fmt.Errorf("%w: file %q of type %q", ErrUnsupportedPart, data.FileURI, data.MIMEType)
+}
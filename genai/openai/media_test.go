@@ -0,0 +1,114 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestConvertMediaParts(t *testing.T) {
+	m := New(Config{ModelName: "gpt-4o"})
+	content := &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{
+			{Text: "What is in these?"},
+			{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}},
+			{InlineData: &genai.Blob{MIMEType: "audio/wav", Data: []byte("wav")}},
+			{InlineData: &genai.Blob{MIMEType: "application/pdf", Data: []byte("pdf"), DisplayName: "report.pdf"}},
+			{FileData: &genai.FileData{MIMEType: "image/jpeg", FileURI: "https://example.com/cat.jpg"}},
+			{FileData: &genai.FileData{MIMEType: "application/pdf", FileURI: "file-abc123"}},
+		},
+	}
+
+	messages, err := m.convertContentToMessages(content)
+	if err != nil {
+		t.Fatalf("convertContentToMessages failed: %v", err)
+	}
+	data, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	var msg struct {
+		Content []map[string]any `json:"content"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+
+	if len(msg.Content) != 6 {
+		t.Fatalf("Expected 6 content parts, got %d: %s", len(msg.Content), data)
+	}
+	wantTypes := []string{"text", "image_url", "input_audio", "file", "image_url", "file"}
+	for i, want := range wantTypes {
+		if msg.Content[i]["type"] != want {
+			t.Errorf("Part %d: expected type %q, got %v", i, want, msg.Content[i]["type"])
+		}
+	}
+
+	if audio := msg.Content[2]["input_audio"].(map[string]any); audio["format"] != "wav" || audio["data"] != "d2F2" {
+		t.Errorf("Unexpected input_audio: %v", audio)
+	}
+	if file := msg.Content[3]["file"].(map[string]any); file["filename"] != "report.pdf" || file["file_data"] != "data:application/pdf;base64,cGRm" {
+		t.Errorf("Unexpected inline file: %v", file)
+	}
+	if url := msg.Content[4]["image_url"].(map[string]any)["url"]; url != "https://example.com/cat.jpg" {
+		t.Errorf("Expected file URI forwarded as URL, got %v", url)
+	}
+	if file := msg.Content[5]["file"].(map[string]any); file["file_id"] != "file-abc123" {
+		t.Errorf("Expected uploaded file ID, got %v", file)
+	}
+}
+
+func TestUnsupportedMediaParts(t *testing.T) {
+	m := New(Config{ModelName: "gpt-4o"})
+	tests := []struct {
+		name    string
+		content *genai.Content
+	}{
+		{
+			name:    "video inline data",
+			content: genai.NewContentFromParts([]*genai.Part{{InlineData: &genai.Blob{MIMEType: "video/mp4", Data: []byte("mp4")}}}, genai.RoleUser),
+		},
+		{
+			name:    "pdf by URL",
+			content: genai.NewContentFromParts([]*genai.Part{{FileData: &genai.FileData{MIMEType: "application/pdf", FileURI: "https://example.com/a.pdf"}}}, genai.RoleUser),
+		},
+		{
+			name:    "media in model message",
+			content: genai.NewContentFromParts([]*genai.Part{{InlineData: &genai.Blob{MIMEType: "image/png", Data: []byte("png")}}}, genai.RoleModel),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.convertContentToMessages(tt.content); !errors.Is(err, ErrUnsupportedPart) {
+				t.Errorf("Expected ErrUnsupportedPart, got %v", err)
+			}
+		})
+	}
+
+	// The Responses API takes PDFs by URL but has no audio input
+	if _, err := m.convertContentToInputItems(tests[1].content); err != nil {
+		t.Errorf("Expected Responses API to accept PDF URL, got %v", err)
+	}
+	audio := genai.NewContentFromParts([]*genai.Part{{InlineData: &genai.Blob{MIMEType: "audio/mpeg", Data: []byte("mp3")}}}, genai.RoleUser)
+	if _, err := m.convertContentToInputItems(audio); !errors.Is(err, ErrUnsupportedPart) {
+		t.Errorf("Expected ErrUnsupportedPart for Responses API audio, got %v", err)
+	}
+}
@@ -18,18 +18,18 @@ package openai
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
 	"strings"
-	"sync"
 
+	"github.com/achetronic/adk-utils-go/genai/internal/jsonschema"
+	"github.com/achetronic/adk-utils-go/genai/internal/media"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/packages/respjson"
+	"github.com/openai/openai-go/v3/responses"
 	"github.com/openai/openai-go/v3/shared"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
@@ -37,23 +37,49 @@ import (
 
 var _ model.LLM = &Model{}
 
+// CandidatesKey is the CustomMetadata key holding the additional choices
+// ([]*genai.Candidate) of a response when CandidateCount is greater than 1.
+const CandidatesKey = "candidates"
+
 var (
 	ErrNoChoicesInResponse = errors.New("no choices in OpenAI response")
+	ErrInvalidSchema       = jsonschema.ErrInvalidSchema
+	ErrUnsupportedPart     = errors.New("content part not supported by OpenAI")
+	ErrBatchUnsupported    = errors.New("batches not supported by this backend")
+	ErrAPIUnsupported      = errors.New("API not supported by this backend")
+	ErrUnknownTool         = errors.New("allowed function name not declared as a tool")
+	ErrBatchFailed         = errors.New("OpenAI batch failed")
+	ErrBatchRequestFailed  = errors.New("batch request failed")
 )
 
-// OpenAI enforces a 40-character limit on tool_call_id fields.
-const maxToolCallIDLength = 40
-
 // Model implements model.LLM using the official OpenAI Go SDK.
 // Works with OpenAI API and compatible providers (Ollama, vLLM, etc.).
 type Model struct {
 	client    *openai.Client
 	modelName string
 
-	// toolCallIDMap stores original IDs when they exceed OpenAI's limit.
-	// Keys are shortened hashes, values are original IDs.
-	toolCallIDMap   map[string]string
-	toolCallIDMapMu sync.RWMutex
+	// streamToolCalls reports tool-call progress as toolcall.Delta metadata
+	// on partial responses while streaming.
+	streamToolCalls bool
+	// includeThoughts forwards provider reasoning output as Thought parts.
+	includeThoughts bool
+
+	// api selects the chat completions or Responses API endpoint.
+	api API
+	// builtinTools are Responses API tools appended to every request.
+	builtinTools []responses.ToolUnionParam
+	// disableStreamUsage omits stream_options.include_usage from streaming requests.
+	disableStreamUsage bool
+	// disableParallelToolCalls limits the model to one tool call per turn.
+	disableParallelToolCalls bool
+	// disableStrictSchema sends structured output schemas as-is with strict=false.
+	disableStrictSchema bool
+
+	// toolCallIDs remembers original IDs that exceed OpenAI's limit,
+	// keyed by their shortened form.
+	toolCallIDs *toolCallIDCache
+	// azure is set when requests go to an Azure OpenAI deployment.
+	azure bool
 }
 
 // Config holds the configuration for creating an OpenAI Model.
@@ -65,25 +91,88 @@ type Config struct {
 	BaseURL string
 	// ModelName specifies which model to use (e.g., "gpt-4o", "qwen3:8b").
 	ModelName string
+	// StreamToolCalls yields partial responses reporting tool names and
+	// arguments as they arrive, as a toolcall.Delta in CustomMetadata under
+	// toolcall.DeltaKey. The FunctionCall parts only come with the final response.
+	StreamToolCalls bool
+	// IncludeThoughts forwards reasoning output (reasoning_content/reasoning
+	// fields from DeepSeek, Ollama, vLLM, etc.) as Thought parts.
+	// When false, reasoning output is discarded.
+	IncludeThoughts bool
+	// API selects the endpoint: APIChatCompletions (default) or APIResponses.
+	// Azure deployments only support APIChatCompletions; requests made with
+	// APIResponses return ErrAPIUnsupported.
+	API API
+	// BuiltinTools are Responses API tools (web search, file search, code
+	// interpreter, etc.) appended to every request. Only used with APIResponses.
+	BuiltinTools []responses.ToolUnionParam
+	// DisableStreamUsage stops streaming requests from setting
+	// stream_options.include_usage. Only needed for providers that reject it.
+	DisableStreamUsage bool
+	// DisableParallelToolCalls sets parallel_tool_calls=false on requests with
+	// tools, so the model calls at most one tool per turn.
+	DisableParallelToolCalls bool
+	// DisableSDKRetries turns off the retries the OpenAI SDK makes on its own
+	// (2 by default) for rate limits, timeouts and server errors. Set it when
+	// retries are handled elsewhere, e.g. by middleware.Retry.
+	DisableSDKRetries bool
+	// DisableStrictSchema sends structured output schemas unchanged with
+	// strict=false. By default they are normalized to OpenAI's strict mode
+	// rules (all properties required, optional ones nullable, no additional
+	// properties) and sent with strict=true; schemas strict mode cannot
+	// express, such as open objects, fail with ErrInvalidSchema.
+	DisableStrictSchema bool
+	// ToolCallIDCacheSize bounds how many shortened tool-call IDs are
+	// remembered to restore the original IDs. Least recently used entries are
+	// evicted first. Defaults to DefaultToolCallIDCacheSize.
+	ToolCallIDCacheSize int
+	// Azure targets an Azure OpenAI deployment instead of BaseURL, with
+	// api-version and api-key (or Entra ID token) authentication. APIKey and
+	// BaseURL are ignored when set, and ModelName defaults to the deployment.
+	Azure *AzureConfig
 }
 
 // New creates a new OpenAI Model with the given configuration.
 func New(cfg Config) *Model {
 	var opts []option.RequestOption
 
-	if cfg.APIKey != "" {
-		opts = append(opts, option.WithAPIKey(cfg.APIKey))
+	if cfg.Azure != nil {
+		opts = append(opts, azureRequestOptions(cfg.Azure)...)
+		if cfg.ModelName == "" {
+			cfg.ModelName = cfg.Azure.Deployment
+		}
+	} else {
+		if cfg.APIKey != "" {
+			opts = append(opts, option.WithAPIKey(cfg.APIKey))
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+		}
 	}
-	if cfg.BaseURL != "" {
-		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+
+	if cfg.DisableSDKRetries {
+		opts = append(opts, option.WithMaxRetries(0))
 	}
 
 	client := openai.NewClient(opts...)
 
+	api := cfg.API
+	if api == "" {
+		api = APIChatCompletions
+	}
+
 	return &Model{
-		client:        &client,
-		modelName:     cfg.ModelName,
-		toolCallIDMap: make(map[string]string),
+		client:                   &client,
+		modelName:                cfg.ModelName,
+		streamToolCalls:          cfg.StreamToolCalls,
+		includeThoughts:          cfg.IncludeThoughts,
+		api:                      api,
+		builtinTools:             cfg.BuiltinTools,
+		disableStreamUsage:       cfg.DisableStreamUsage,
+		disableParallelToolCalls: cfg.DisableParallelToolCalls,
+		disableStrictSchema:      cfg.DisableStrictSchema,
+		toolCallIDs:              newToolCallIDCache(cfg.ToolCallIDCacheSize),
+		azure:                    cfg.Azure != nil,
 	}
 }
 
@@ -95,6 +184,17 @@ func (m *Model) Name() string {
 // GenerateContent sends a request to the LLM and returns responses.
 // Set stream=true for streaming responses, false for a single response.
 func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if m.api == APIResponses {
+		if m.azure {
+			return func(yield func(*model.LLMResponse, error) bool) {
+				yield(nil, ErrAPIUnsupported)
+			}
+		}
+		if stream {
+			return m.generateResponsesStream(ctx, req)
+		}
+		return m.generateResponses(ctx, req)
+	}
 	if stream {
 		return m.generateStream(ctx, req)
 	}
@@ -112,7 +212,7 @@ func (m *Model) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*
 
 		resp, err := m.client.Chat.Completions.New(ctx, params)
 		if err != nil {
-			yield(nil, err)
+			yield(nil, classifyError(err))
 			return
 		}
 
@@ -136,19 +236,37 @@ func (m *Model) generateStream(ctx context.Context, req *model.LLMRequest) iter.
 			return
 		}
 
+		// Most providers only report usage for streams when asked to
+		if !m.disableStreamUsage {
+			params.StreamOptions.IncludeUsage = openai.Bool(true)
+		}
+
 		stream := m.client.Chat.Completions.NewStreaming(ctx, params)
 		acc := openai.ChatCompletionAccumulator{}
+		toolCalls := newToolCallStream(func(id string, index int64, name string) string {
+			return m.resolveToolCallID(id, acc.ID, index, name)
+		})
+
+		// The accumulator ignores non-standard fields, so reasoning is collected here
+		var reasoning strings.Builder
 
 		// Yield partial responses as chunks arrive
 		for stream.Next() {
 			chunk := stream.Current()
 			acc.AddChunk(chunk)
 
-			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			// Partial responses only follow the first choice
+			choice, ok := firstChunkChoice(chunk)
+			if !ok {
+				continue
+			}
+
+			if thought := extractReasoning(choice.Delta.JSON.ExtraFields); thought != "" && m.includeThoughts {
+				reasoning.WriteString(thought)
 				llmResp := &model.LLMResponse{
 					Content: &genai.Content{
 						Role:  genai.RoleModel,
-						Parts: []*genai.Part{{Text: chunk.Choices[0].Delta.Content}},
+						Parts: []*genai.Part{{Text: thought, Thought: true}},
 					},
 					Partial:      true,
 					TurnComplete: false,
@@ -157,55 +275,66 @@ func (m *Model) generateStream(ctx context.Context, req *model.LLMRequest) iter.
 					return
 				}
 			}
+
+			if choice.Delta.Content != "" {
+				llmResp := &model.LLMResponse{
+					Content: &genai.Content{
+						Role:  genai.RoleModel,
+						Parts: []*genai.Part{{Text: choice.Delta.Content}},
+					},
+					Partial:      true,
+					TurnComplete: false,
+				}
+				if !yield(llmResp, nil) {
+					return
+				}
+			}
+
+			if m.streamToolCalls {
+				for _, llmResp := range toolCalls.update(choice) {
+					if !yield(llmResp, nil) {
+						return
+					}
+				}
+			}
 		}
 
 		if err := stream.Err(); err != nil {
-			yield(nil, err)
+			yield(nil, classifyError(err))
 			return
 		}
 
+		if m.streamToolCalls {
+			for _, llmResp := range toolCalls.finish() {
+				if !yield(llmResp, nil) {
+					return
+				}
+			}
+		}
+
 		// Build and yield final aggregated response
-		yield(m.buildStreamFinalResponse(&acc), nil)
+		yield(m.buildStreamFinalResponse(&acc, reasoning.String()), nil)
 	}
 }
 
 // buildStreamFinalResponse creates the final LLMResponse from accumulated stream data.
-func (m *Model) buildStreamFinalResponse(acc *openai.ChatCompletionAccumulator) *model.LLMResponse {
-	content := &genai.Content{
-		Role:  genai.RoleModel,
-		Parts: []*genai.Part{},
-	}
-
-	if len(acc.Choices) > 0 {
-		choice := acc.Choices[0]
-
-		if choice.Message.Content != "" {
-			content.Parts = append(content.Parts, &genai.Part{Text: choice.Message.Content})
+func (m *Model) buildStreamFinalResponse(acc *openai.ChatCompletionAccumulator, reasoning string) *model.LLMResponse {
+	if len(acc.Choices) == 0 {
+		content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{}}
+		if reasoning != "" && m.includeThoughts {
+			content.Parts = append(content.Parts, &genai.Part{Text: reasoning, Thought: true})
 		}
-
-		for _, tc := range choice.Message.ToolCalls {
-			content.Parts = append(content.Parts, &genai.Part{
-				FunctionCall: &genai.FunctionCall{
-					ID:   tc.ID,
-					Name: tc.Function.Name,
-					Args: parseJSONArgs(tc.Function.Arguments),
-				},
-			})
+		return &model.LLMResponse{
+			Content:       content,
+			UsageMetadata: convertUsageMetadata(acc.Usage),
+			TurnComplete:  true,
 		}
 	}
 
-	var finishReason genai.FinishReason
-	if len(acc.Choices) > 0 {
-		finishReason = convertFinishReason(string(acc.Choices[0].FinishReason))
-	}
+	// Reasoning deltas are only collected for the first choice
+	reasonings := map[int64]string{0: reasoning}
 
-	return &model.LLMResponse{
-		Content:       content,
-		UsageMetadata: convertUsageMetadata(acc.Usage),
-		FinishReason:  finishReason,
-		Partial:       false,
-		TurnComplete:  true,
-	}
+	return m.buildResponse(&acc.ChatCompletion, reasonings)
 }
 
 // buildChatCompletionParams converts an LLMRequest into OpenAI API parameters.
@@ -235,14 +364,16 @@ func (m *Model) buildChatCompletionParams(req *model.LLMRequest) (openai.ChatCom
 
 	// Apply optional configuration
 	if req.Config != nil {
-		m.applyGenerationConfig(&params, req.Config)
+		if err := m.applyGenerationConfig(&params, req.Config); err != nil {
+			return openai.ChatCompletionNewParams{}, err
+		}
 	}
 
 	return params, nil
 }
 
 // applyGenerationConfig applies optional generation settings to the request params.
-func (m *Model) applyGenerationConfig(params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig) {
+func (m *Model) applyGenerationConfig(params *openai.ChatCompletionNewParams, cfg *genai.GenerateContentConfig) error {
 	if cfg.Temperature != nil {
 		params.Temperature = openai.Float(float64(*cfg.Temperature))
 	}
@@ -264,6 +395,28 @@ func (m *Model) applyGenerationConfig(params *openai.ChatCompletionNewParams, cf
 		}
 	}
 
+	// Sampling
+	if cfg.Seed != nil {
+		params.Seed = openai.Int(int64(*cfg.Seed))
+	}
+	if cfg.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(float64(*cfg.PresencePenalty))
+	}
+	if cfg.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(float64(*cfg.FrequencyPenalty))
+	}
+	if cfg.CandidateCount > 1 {
+		params.N = openai.Int(int64(cfg.CandidateCount))
+	}
+
+	// Token logprobs, with the top alternatives when Logprobs is set
+	if cfg.ResponseLogprobs || cfg.Logprobs != nil {
+		params.Logprobs = openai.Bool(true)
+	}
+	if cfg.Logprobs != nil {
+		params.TopLogprobs = openai.Int(int64(*cfg.Logprobs))
+	}
+
 	// Reasoning effort (for o-series models)
 	if cfg.ThinkingConfig != nil {
 		params.ReasoningEffort = convertThinkingLevel(cfg.ThinkingConfig.ThinkingLevel)
@@ -277,27 +430,132 @@ func (m *Model) applyGenerationConfig(params *openai.ChatCompletionNewParams, cf
 	}
 
 	// Structured output with schema
-	if cfg.ResponseSchema != nil {
-		if schemaMap, err := convertSchema(cfg.ResponseSchema); err == nil {
-			params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
-				OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
-					JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
-						Name:        "response",
-						Description: openai.String(cfg.ResponseSchema.Description),
-						Schema:      schemaMap,
-						Strict:      openai.Bool(true),
-					},
-				},
-			}
+	schema, err := m.buildResponseSchema(cfg)
+	if err != nil {
+		return err
+	}
+	if schema != nil {
+		jsonSchema := openai.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   "response",
+			Schema: schema,
+			Strict: openai.Bool(!m.disableStrictSchema),
+		}
+		if description, _ := schema["description"].(string); description != "" {
+			jsonSchema.Description = openai.String(description)
+		}
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{JSONSchema: jsonSchema},
 		}
 	}
 
 	// Tools
 	if len(cfg.Tools) > 0 {
-		if tools, err := m.convertTools(cfg.Tools); err == nil {
-			params.Tools = tools
+		tools, err := m.convertTools(cfg.Tools)
+		if err != nil {
+			return err
 		}
+		params.Tools = tools
 	}
+
+	// Tool choice and parallel calls only apply when tools are sent
+	if len(params.Tools) > 0 {
+		choice, ok, err := resolveToolChoice(cfg.ToolConfig, cfg.Tools)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params.ToolChoice = convertToolChoice(choice)
+		}
+		if m.disableParallelToolCalls {
+			params.ParallelToolCalls = openai.Bool(false)
+		}
+	}
+
+	return nil
+}
+
+// toolChoice is the provider-neutral form of a genai FunctionCallingConfig.
+type toolChoice struct {
+	// mode is one of "auto", "required" or "none".
+	mode  string
+	names []string
+}
+
+// resolveToolChoice reads the function calling mode and allowed names from a
+// genai ToolConfig. VALIDATED behaves like AUTO, as schema validation is
+// controlled separately through strict mode. Returns false when unspecified,
+// and ErrUnknownTool if an allowed name is not declared in tools.
+func resolveToolChoice(cfg *genai.ToolConfig, tools []*genai.Tool) (toolChoice, bool, error) {
+	if cfg == nil || cfg.FunctionCallingConfig == nil {
+		return toolChoice{}, false, nil
+	}
+
+	fcc := cfg.FunctionCallingConfig
+	switch fcc.Mode {
+	case genai.FunctionCallingConfigModeNone:
+		return toolChoice{mode: "none"}, true, nil
+	case genai.FunctionCallingConfigModeAny, genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeValidated:
+	default:
+		return toolChoice{}, false, nil
+	}
+
+	for _, name := range fcc.AllowedFunctionNames {
+		if !declaresFunction(tools, name) {
+			return toolChoice{}, false, fmt.Errorf("%w: %s", ErrUnknownTool, name)
+		}
+	}
+	if fcc.Mode == genai.FunctionCallingConfigModeAny {
+		return toolChoice{mode: "required", names: fcc.AllowedFunctionNames}, true, nil
+	}
+	return toolChoice{mode: "auto", names: fcc.AllowedFunctionNames}, true, nil
+}
+
+// declaresFunction reports whether a function declaration has the given name.
+func declaresFunction(tools []*genai.Tool, name string) bool {
+	for _, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		for _, decl := range tool.FunctionDeclarations {
+			if decl.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convertToolChoice maps a toolChoice to OpenAI's tool_choice. A single
+// required name forces that function; several names become an allowed_tools list.
+func convertToolChoice(choice toolChoice) openai.ChatCompletionToolChoiceOptionUnionParam {
+	if choice.mode == "required" && len(choice.names) == 1 {
+		return openai.ToolChoiceOptionFunctionToolChoice(openai.ChatCompletionNamedToolChoiceFunctionParam{
+			Name: choice.names[0],
+		})
+	}
+	if choice.mode != "none" && len(choice.names) > 0 {
+		return openai.ChatCompletionToolChoiceOptionUnionParam{
+			OfAllowedTools: &openai.ChatCompletionAllowedToolChoiceParam{
+				AllowedTools: openai.ChatCompletionAllowedToolsParam{
+					Mode:  openai.ChatCompletionAllowedToolsMode(choice.mode),
+					Tools: allowedFunctionTools(choice.names),
+				},
+			},
+		}
+	}
+	return openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(choice.mode)}
+}
+
+// allowedFunctionTools builds the tool references used by allowed_tools.
+func allowedFunctionTools(names []string) []map[string]any {
+	tools := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, map[string]any{
+			"type":     "function",
+			"function": map[string]any{"name": name},
+		})
+	}
+	return tools
 }
 
 // convertContentToMessages converts a genai.Content into OpenAI message format.
@@ -306,10 +564,14 @@ func (m *Model) convertContentToMessages(content *genai.Content) ([]openai.ChatC
 	var messages []openai.ChatCompletionMessageParamUnion
 	var textParts []string
 	var toolCalls []openai.ChatCompletionMessageToolCallUnionParam
-	var imageParts []openai.ChatCompletionContentPartImageParam
+	var mediaParts []openai.ChatCompletionContentPartUnionParam
 
 	for _, part := range content.Parts {
 		switch {
+		case part.Thought:
+			// Reasoning output must not be sent back as regular content
+			continue
+
 		case part.FunctionResponse != nil:
 			// Tool responses become separate messages
 			responseJSON, err := json.Marshal(part.FunctionResponse.Response)
@@ -339,16 +601,22 @@ func (m *Model) convertContentToMessages(content *genai.Content) ([]openai.ChatC
 		case part.Text != "":
 			textParts = append(textParts, part.Text)
 
-		case part.InlineData != nil:
-			if img := convertInlineDataToImage(part.InlineData); img != nil {
-				imageParts = append(imageParts, *img)
+		case media.IsPart(part):
+			// Only user messages can carry media
+			if convertRole(content.Role) != "user" {
+				return nil, fmt.Errorf("%w: media in %s messages", ErrUnsupportedPart, convertRole(content.Role))
+			}
+			mediaPart, err := convertMediaPart(part)
+			if err != nil {
+				return nil, err
 			}
+			mediaParts = append(mediaParts, mediaPart)
 		}
 	}
 
 	// Build role-specific message if there's content
-	if len(textParts) > 0 || len(imageParts) > 0 || len(toolCalls) > 0 {
-		msg := m.buildRoleMessage(content.Role, textParts, imageParts, toolCalls)
+	if len(textParts) > 0 || len(mediaParts) > 0 || len(toolCalls) > 0 {
+		msg := m.buildRoleMessage(content.Role, textParts, mediaParts, toolCalls)
 		if msg != nil {
 			messages = append(messages, *msg)
 		}
@@ -358,10 +626,10 @@ func (m *Model) convertContentToMessages(content *genai.Content) ([]openai.ChatC
 }
 
 // buildRoleMessage creates the appropriate message type based on role.
-func (m *Model) buildRoleMessage(role string, texts []string, images []openai.ChatCompletionContentPartImageParam, toolCalls []openai.ChatCompletionMessageToolCallUnionParam) *openai.ChatCompletionMessageParamUnion {
+func (m *Model) buildRoleMessage(role string, texts []string, media []openai.ChatCompletionContentPartUnionParam, toolCalls []openai.ChatCompletionMessageToolCallUnionParam) *openai.ChatCompletionMessageParamUnion {
 	switch convertRole(role) {
 	case "user":
-		return buildUserMessage(texts, images)
+		return buildUserMessage(texts, media)
 	case "assistant":
 		return buildAssistantMessage(texts, toolCalls)
 	case "system":
@@ -371,25 +639,21 @@ func (m *Model) buildRoleMessage(role string, texts []string, images []openai.Ch
 	return nil
 }
 
-// buildUserMessage creates a user message, with multi-part support for images.
-func buildUserMessage(texts []string, images []openai.ChatCompletionContentPartImageParam) *openai.ChatCompletionMessageParamUnion {
-	if len(images) == 0 {
+// buildUserMessage creates a user message, with multi-part support for media.
+func buildUserMessage(texts []string, media []openai.ChatCompletionContentPartUnionParam) *openai.ChatCompletionMessageParamUnion {
+	if len(media) == 0 {
 		msg := openai.UserMessage(joinTexts(texts))
 		return &msg
 	}
 
-	// Multi-part message with images
+	// Multi-part message with media
 	var parts []openai.ChatCompletionContentPartUnionParam
 	for _, text := range texts {
 		parts = append(parts, openai.ChatCompletionContentPartUnionParam{
 			OfText: &openai.ChatCompletionContentPartTextParam{Text: text},
 		})
 	}
-	for _, img := range images {
-		parts = append(parts, openai.ChatCompletionContentPartUnionParam{
-			OfImageURL: &img,
-		})
-	}
+	parts = append(parts, media...)
 
 	return &openai.ChatCompletionMessageParamUnion{
 		OfUser: &openai.ChatCompletionUserMessageParam{
@@ -422,32 +686,78 @@ func (m *Model) convertResponse(resp *openai.ChatCompletion) (*model.LLMResponse
 		return nil, ErrNoChoicesInResponse
 	}
 
-	choice := resp.Choices[0]
+	reasonings := make(map[int64]string, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		reasonings[choice.Index] = extractReasoning(choice.Message.JSON.ExtraFields)
+	}
+
+	return m.buildResponse(resp, reasonings), nil
+}
+
+// buildResponse builds the LLMResponse for a completion with at least one
+// choice. The first choice becomes the response; when more were requested
+// (CandidateCount > 1), the others are exposed as genai.Candidates under
+// CandidatesKey in CustomMetadata.
+func (m *Model) buildResponse(resp *openai.ChatCompletion, reasonings map[int64]string) *model.LLMResponse {
+	candidates := make([]*genai.Candidate, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		candidates = append(candidates, m.convertChoice(choice, resp.ID, reasonings[choice.Index]))
+	}
+
+	first := candidates[0]
+	llmResp := &model.LLMResponse{
+		Content:        first.Content,
+		UsageMetadata:  convertUsageMetadata(resp.Usage),
+		FinishReason:   first.FinishReason,
+		LogprobsResult: first.LogprobsResult,
+		AvgLogprobs:    first.AvgLogprobs,
+		TurnComplete:   true,
+	}
+	if len(candidates) > 1 {
+		llmResp.CustomMetadata = map[string]any{CandidatesKey: candidates[1:]}
+	}
+
+	return llmResp
+}
+
+// convertChoice transforms a completion choice into a genai.Candidate.
+func (m *Model) convertChoice(choice openai.ChatCompletionChoice, responseID, reasoning string) *genai.Candidate {
 	content := &genai.Content{
 		Role:  genai.RoleModel,
 		Parts: []*genai.Part{},
 	}
 
+	if reasoning != "" && m.includeThoughts {
+		content.Parts = append(content.Parts, &genai.Part{Text: reasoning, Thought: true})
+	}
+
 	if choice.Message.Content != "" {
 		content.Parts = append(content.Parts, &genai.Part{Text: choice.Message.Content})
 	}
 
-	for _, tc := range choice.Message.ToolCalls {
+	// Keep generated IDs of other choices distinct from the first one's
+	if choice.Index > 0 {
+		responseID = fmt.Sprintf("%s:%d", responseID, choice.Index)
+	}
+	for i, tc := range choice.Message.ToolCalls {
 		content.Parts = append(content.Parts, &genai.Part{
 			FunctionCall: &genai.FunctionCall{
-				ID:   tc.ID,
+				ID:   m.resolveToolCallID(tc.ID, responseID, int64(i), tc.Function.Name),
 				Name: tc.Function.Name,
 				Args: parseJSONArgs(tc.Function.Arguments),
 			},
 		})
 	}
 
-	return &model.LLMResponse{
-		Content:       content,
-		UsageMetadata: convertUsageMetadata(resp.Usage),
-		FinishReason:  convertFinishReason(string(choice.FinishReason)),
-		TurnComplete:  true,
-	}, nil
+	logprobs, avgLogprob := convertLogprobs(choice.Logprobs.Content)
+
+	return &genai.Candidate{
+		Content:        content,
+		FinishReason:   convertFinishReason(string(choice.FinishReason)),
+		LogprobsResult: logprobs,
+		AvgLogprobs:    avgLogprob,
+		Index:          int32(choice.Index),
+	}
 }
 
 // convertTools transforms genai tools into OpenAI function tool format.
@@ -465,10 +775,15 @@ func (m *Model) convertTools(genaiTools []*genai.Tool) ([]openai.ChatCompletionT
 				params = funcDecl.Parameters
 			}
 
+			funcParams, err := convertToFunctionParams(params)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert parameters of tool %q: %w", funcDecl.Name, err)
+			}
+
 			tools = append(tools, openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
 				Name:        funcDecl.Name,
 				Description: openai.String(funcDecl.Description),
-				Parameters:  convertToFunctionParams(params),
+				Parameters:  funcParams,
 			}))
 		}
 	}
@@ -476,154 +791,46 @@ func (m *Model) convertTools(genaiTools []*genai.Tool) ([]openai.ChatCompletionT
 	return tools, nil
 }
 
-// convertToFunctionParams converts various parameter types to OpenAI format.
-// OpenAI requires object schemas to have a "properties" field, even if empty.
-func convertToFunctionParams(params any) shared.FunctionParameters {
-	if params == nil {
-		return nil
-	}
-
-	var m map[string]any
-
-	// Direct map
-	if dm, ok := params.(map[string]any); ok {
-		m = dm
-	} else {
-		// Convert via JSON for other types (e.g., *jsonschema.Schema)
-		jsonBytes, err := json.Marshal(params)
-		if err != nil {
-			return nil
-		}
-		if json.Unmarshal(jsonBytes, &m) != nil {
-			return nil
-		}
-	}
-
-	// OpenAI requires "properties" for object types
-	ensureObjectProperties(m)
-
-	return shared.FunctionParameters(m)
-}
-
-// ensureObjectProperties recursively ensures all object schemas have a properties field.
-func ensureObjectProperties(schema map[string]any) {
-	if schema == nil {
-		return
-	}
-
-	// If type is "object" and no properties, add empty properties
-	if t, ok := schema["type"].(string); ok && t == "object" {
-		if _, hasProps := schema["properties"]; !hasProps {
-			schema["properties"] = map[string]any{}
-		}
-	}
+// --- Helper functions ---
 
-	// Recursively process nested properties
-	if props, ok := schema["properties"].(map[string]any); ok {
-		for _, prop := range props {
-			if propMap, ok := prop.(map[string]any); ok {
-				ensureObjectProperties(propMap)
-			}
+// firstChunkChoice returns the delta of the first choice (index 0) in a chunk.
+// With n>1, chunks interleave deltas of every choice.
+func firstChunkChoice(chunk openai.ChatCompletionChunk) (openai.ChatCompletionChunkChoice, bool) {
+	for _, choice := range chunk.Choices {
+		if choice.Index == 0 {
+			return choice, true
 		}
 	}
-
-	// Process array items
-	if items, ok := schema["items"].(map[string]any); ok {
-		ensureObjectProperties(items)
-	}
+	return openai.ChatCompletionChunkChoice{}, false
 }
 
-// convertSchema recursively converts a genai.Schema to OpenAI JSON schema format.
-func convertSchema(schema *genai.Schema) (map[string]any, error) {
-	if schema == nil {
-		return map[string]any{"type": "object", "properties": map[string]any{}}, nil
+// convertLogprobs maps token logprobs to a genai.LogprobsResult and returns
+// it with the average logprob. Returns nil when no logprobs were returned.
+func convertLogprobs(tokens []openai.ChatCompletionTokenLogprob) (*genai.LogprobsResult, float64) {
+	if len(tokens) == 0 {
+		return nil, 0
 	}
 
-	result := make(map[string]any)
-
-	if schema.Type != genai.TypeUnspecified {
-		result["type"] = schemaTypeToString(schema.Type)
-	}
-	if schema.Description != "" {
-		result["description"] = schema.Description
-	}
-	if len(schema.Required) > 0 {
-		result["required"] = schema.Required
-	}
-	if len(schema.Enum) > 0 {
-		result["enum"] = schema.Enum
-	}
-
-	if len(schema.Properties) > 0 {
-		props := make(map[string]any)
-		for name, propSchema := range schema.Properties {
-			converted, err := convertSchema(propSchema)
-			if err != nil {
-				return nil, err
-			}
-			props[name] = converted
-		}
-		result["properties"] = props
-	}
+	result := &genai.LogprobsResult{}
+	var sum float64
+	for _, token := range tokens {
+		sum += token.Logprob
+		result.ChosenCandidates = append(result.ChosenCandidates, &genai.LogprobsResultCandidate{
+			Token:          token.Token,
+			LogProbability: float32(token.Logprob),
+		})
 
-	if schema.Items != nil {
-		items, err := convertSchema(schema.Items)
-		if err != nil {
-			return nil, err
+		top := &genai.LogprobsResultTopCandidates{}
+		for _, candidate := range token.TopLogprobs {
+			top.Candidates = append(top.Candidates, &genai.LogprobsResultCandidate{
+				Token:          candidate.Token,
+				LogProbability: float32(candidate.Logprob),
+			})
 		}
-		result["items"] = items
-	}
-
-	return result, nil
-}
-
-// normalizeToolCallID shortens IDs exceeding OpenAI's 40-char limit using a hash.
-// The mapping is stored to allow reverse lookup if needed.
-func (m *Model) normalizeToolCallID(id string) string {
-	if len(id) <= maxToolCallIDLength {
-		return id
-	}
-
-	hash := sha256.Sum256([]byte(id))
-	shortID := "tc_" + hex.EncodeToString(hash[:])[:maxToolCallIDLength-3]
-
-	m.toolCallIDMapMu.Lock()
-	m.toolCallIDMap[shortID] = id
-	m.toolCallIDMapMu.Unlock()
-
-	return shortID
-}
-
-// denormalizeToolCallID restores the original ID from a shortened one.
-func (m *Model) denormalizeToolCallID(shortID string) string {
-	m.toolCallIDMapMu.RLock()
-	defer m.toolCallIDMapMu.RUnlock()
-
-	if original, exists := m.toolCallIDMap[shortID]; exists {
-		return original
+		result.TopCandidates = append(result.TopCandidates, top)
 	}
-	return shortID
-}
-
-// --- Helper functions ---
 
-// convertInlineDataToImage converts inline image data to OpenAI format.
-func convertInlineDataToImage(data *genai.Blob) *openai.ChatCompletionContentPartImageParam {
-	supportedTypes := map[string]bool{
-		"image/jpg": true, "image/jpeg": true, "image/png": true,
-		"image/gif": true, "image/webp": true,
-	}
-
-	if !supportedTypes[data.MIMEType] {
-		return nil
-	}
-
-	return &openai.ChatCompletionContentPartImageParam{
-		ImageURL: openai.ChatCompletionContentPartImageImageURLParam{
-			URL:    fmt.Sprintf("data:%s;base64,%s", data.MIMEType, base64.StdEncoding.EncodeToString(data.Data)),
-			Detail: "auto",
-		},
-	}
+	return result, sum / float64(len(tokens))
 }
 
 // convertUsageMetadata converts OpenAI usage stats to genai format.
@@ -632,10 +839,32 @@ func convertUsageMetadata(usage openai.CompletionUsage) *genai.GenerateContentRe
 		return nil
 	}
 	return &genai.GenerateContentResponseUsageMetadata{
-		PromptTokenCount:     int32(usage.PromptTokens),
-		CandidatesTokenCount: int32(usage.CompletionTokens),
-		TotalTokenCount:      int32(usage.TotalTokens),
+		PromptTokenCount:        int32(usage.PromptTokens),
+		CachedContentTokenCount: int32(usage.PromptTokensDetails.CachedTokens),
+		CandidatesTokenCount:    int32(usage.CompletionTokens),
+		ThoughtsTokenCount:      int32(usage.CompletionTokensDetails.ReasoningTokens),
+		TotalTokenCount:         int32(usage.TotalTokens),
+	}
+}
+
+// reasoningFields lists the non-standard fields OpenAI-compatible providers use
+// for reasoning output: DeepSeek and vLLM send "reasoning_content", while
+// Ollama and OpenRouter send "reasoning".
+var reasoningFields = []string{"reasoning_content", "reasoning"}
+
+// extractReasoning returns the reasoning text found in a message or delta's extra fields.
+func extractReasoning(extraFields map[string]respjson.Field) string {
+	for _, key := range reasoningFields {
+		field, ok := extraFields[key]
+		if !ok {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal([]byte(field.Raw()), &text); err == nil && text != "" {
+			return text
+		}
 	}
+	return ""
 }
 
 // convertRole maps genai roles to OpenAI roles.
@@ -672,22 +901,6 @@ func convertThinkingLevel(level genai.ThinkingLevel) shared.ReasoningEffort {
 	}
 }
 
-// schemaTypeToString converts genai.Type to JSON schema type string.
-func schemaTypeToString(t genai.Type) string {
-	types := map[genai.Type]string{
-		genai.TypeString:  "string",
-		genai.TypeNumber:  "number",
-		genai.TypeInteger: "integer",
-		genai.TypeBoolean: "boolean",
-		genai.TypeArray:   "array",
-		genai.TypeObject:  "object",
-	}
-	if s, ok := types[t]; ok {
-		return s
-	}
-	return "string"
-}
-
 // extractText extracts all text parts from a Content and joins them.
 func extractText(content *genai.Content) string {
 	if content == nil {
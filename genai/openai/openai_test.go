@@ -0,0 +1,483 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// newTestServer starts a mock OpenAI-compatible server that replies to every
+// request with the given handler.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeSSE writes the given chunks as a server-sent event stream.
+func writeSSE(t *testing.T, w http.ResponseWriter, chunks ...map[string]any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			// Handlers run outside the test goroutine, where t.Fatalf is not allowed
+			t.Errorf("Failed to marshal chunk: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// streamChunk builds a chat.completion.chunk with a single choice delta.
+func streamChunk(delta map[string]any, finishReason string) map[string]any {
+	choice := map[string]any{"index": 0, "delta": delta}
+	if finishReason != "" {
+		choice["finish_reason"] = finishReason
+	}
+	return map[string]any{
+		"id":      "chatcmpl-test",
+		"object":  "chat.completion.chunk",
+		"model":   "test-model",
+		"choices": []any{choice},
+	}
+}
+
+// collect drains a GenerateContent iterator, failing the test on error.
+func collect(t *testing.T, m *Model, req *model.LLMRequest, stream bool) []*model.LLMResponse {
+	t.Helper()
+	var responses []*model.LLMResponse
+	for resp, err := range m.GenerateContent(context.Background(), req, stream) {
+		if err != nil {
+			t.Fatalf("GenerateContent failed: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func userRequest(text string) *model.LLMRequest {
+	return &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText(text, genai.RoleUser)},
+	}
+}
+
+func TestStreamToolCallDeltas(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w,
+			streamChunk(map[string]any{"role": "assistant", "tool_calls": []any{
+				map[string]any{"index": 0, "id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": ""}},
+			}}, ""),
+			streamChunk(map[string]any{"tool_calls": []any{
+				map[string]any{"index": 0, "function": map[string]any{"arguments": `{"city":`}},
+			}}, ""),
+			streamChunk(map[string]any{"tool_calls": []any{
+				map[string]any{"index": 0, "function": map[string]any{"arguments": `"Paris"}`}},
+			}}, ""),
+			streamChunk(map[string]any{"tool_calls": []any{
+				map[string]any{"index": 1, "id": "call_2", "type": "function", "function": map[string]any{"name": "get_time", "arguments": `{}`}},
+			}}, ""),
+			streamChunk(map[string]any{}, "tool_calls"),
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model", StreamToolCalls: true})
+	responses := collect(t, m, userRequest("weather?"), true)
+
+	type step struct {
+		index    int
+		id       string
+		fragment string
+		complete bool
+	}
+	want := []step{
+		{index: 0, id: "call_1"},
+		{index: 0, id: "call_1", fragment: `{"city":`},
+		{index: 0, id: "call_1", fragment: `"Paris"}`},
+		{index: 0, id: "call_1", complete: true},
+		{index: 1, id: "call_2", fragment: `{}`},
+		{index: 1, id: "call_2", complete: true},
+	}
+
+	if len(responses) != len(want)+1 {
+		t.Fatalf("Expected %d responses, got %d", len(want)+1, len(responses))
+	}
+
+	for i, w := range want {
+		resp := responses[i]
+		if !resp.Partial {
+			t.Errorf("Response %d: expected partial", i)
+		}
+		delta, ok := resp.CustomMetadata[toolcall.DeltaKey].(toolcall.Delta)
+		if !ok {
+			t.Fatalf("Response %d: expected a toolcall.Delta, got %v", i, resp.CustomMetadata)
+		}
+		if len(resp.Content.Parts) != 0 {
+			t.Errorf("Response %d: expected no parts, got %+v", i, resp.Content.Parts)
+		}
+		if delta.Index != w.index || delta.ID != w.id || delta.Done != w.complete || delta.ArgumentsDelta != w.fragment {
+			t.Errorf("Response %d: expected %+v, got %+v", i, w, delta)
+		}
+	}
+
+	if args := responses[3].CustomMetadata[toolcall.DeltaKey].(toolcall.Delta).Args; args["city"] != "Paris" {
+		t.Errorf("Expected completed args city=Paris, got %v", args)
+	}
+
+	final := responses[len(responses)-1]
+	if final.Partial || !final.TurnComplete {
+		t.Errorf("Expected final response to be complete")
+	}
+	if len(final.Content.Parts) != 2 {
+		t.Fatalf("Expected 2 function calls in final response, got %d", len(final.Content.Parts))
+	}
+	if final.Content.Parts[0].FunctionCall.Name != "get_weather" || final.Content.Parts[1].FunctionCall.Name != "get_time" {
+		t.Errorf("Unexpected final function calls: %+v, %+v", final.Content.Parts[0].FunctionCall, final.Content.Parts[1].FunctionCall)
+	}
+}
+
+func TestStreamToolCallDeltasDisabled(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w,
+			streamChunk(map[string]any{"role": "assistant", "tool_calls": []any{
+				map[string]any{"index": 0, "id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{}`}},
+			}}, ""),
+			streamChunk(map[string]any{}, "tool_calls"),
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	responses := collect(t, m, userRequest("weather?"), true)
+
+	if len(responses) != 1 {
+		t.Fatalf("Expected only the final response, got %d", len(responses))
+	}
+	if responses[0].Content.Parts[0].FunctionCall.Name != "get_weather" {
+		t.Errorf("Expected final function call get_weather")
+	}
+}
+
+func TestReasoningContent(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":     "chatcmpl-test",
+			"object": "chat.completion",
+			"model":  "deepseek-reasoner",
+			"choices": []any{map[string]any{
+				"index":         0,
+				"finish_reason": "stop",
+				"message": map[string]any{
+					"role":              "assistant",
+					"content":           "42",
+					"reasoning_content": "Let me think...",
+				},
+			}},
+			"usage": map[string]any{
+				"prompt_tokens":             10,
+				"completion_tokens":         20,
+				"total_tokens":              30,
+				"completion_tokens_details": map[string]any{"reasoning_tokens": 15},
+			},
+		})
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "deepseek-reasoner", IncludeThoughts: true})
+	responses := collect(t, m, userRequest("question"), false)
+
+	parts := responses[0].Content.Parts
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+	if !parts[0].Thought || parts[0].Text != "Let me think..." {
+		t.Errorf("Expected thought part first, got %+v", parts[0])
+	}
+	if parts[1].Thought || parts[1].Text != "42" {
+		t.Errorf("Expected answer text part, got %+v", parts[1])
+	}
+	if got := responses[0].UsageMetadata.ThoughtsTokenCount; got != 15 {
+		t.Errorf("Expected 15 thoughts tokens, got %d", got)
+	}
+
+	// Discarded when thoughts are not included
+	m = New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "deepseek-reasoner"})
+	responses = collect(t, m, userRequest("question"), false)
+	if parts := responses[0].Content.Parts; len(parts) != 1 || parts[0].Thought {
+		t.Errorf("Expected only the answer part, got %+v", parts)
+	}
+}
+
+func TestStreamReasoning(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w,
+			streamChunk(map[string]any{"role": "assistant", "reasoning": "Think "}, ""),
+			streamChunk(map[string]any{"reasoning": "hard."}, ""),
+			streamChunk(map[string]any{"content": "Done"}, ""),
+			streamChunk(map[string]any{}, "stop"),
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "qwen3", IncludeThoughts: true})
+	responses := collect(t, m, userRequest("question"), true)
+
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d", len(responses))
+	}
+	for i := 0; i < 2; i++ {
+		if !responses[i].Partial || !responses[i].Content.Parts[0].Thought {
+			t.Errorf("Response %d: expected partial thought", i)
+		}
+	}
+
+	final := responses[3].Content.Parts
+	if len(final) != 2 || !final[0].Thought || final[0].Text != "Think hard." || final[1].Text != "Done" {
+		t.Errorf("Unexpected final parts: %+v", final)
+	}
+}
+
+func TestThoughtPartsNotSentBack(t *testing.T) {
+	m := New(Config{ModelName: "test-model"})
+	msgs, err := m.convertContentToMessages(&genai.Content{
+		Role: genai.RoleModel,
+		Parts: []*genai.Part{
+			{Text: "hidden reasoning", Thought: true},
+			{Text: "visible answer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("convertContentToMessages failed: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].OfAssistant == nil {
+		t.Fatalf("Expected a single assistant message, got %+v", msgs)
+	}
+	if got := msgs[0].OfAssistant.Content.OfString.Value; got != "visible answer" {
+		t.Errorf("Expected only visible text, got %q", got)
+	}
+}
+
+func TestStreamUsage(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reqBody = nil
+		json.NewDecoder(r.Body).Decode(&reqBody)
+
+		usageChunk := streamChunk(map[string]any{}, "")
+		usageChunk["choices"] = []any{}
+		usageChunk["usage"] = map[string]any{
+			"prompt_tokens":             100,
+			"completion_tokens":         20,
+			"total_tokens":              120,
+			"prompt_tokens_details":     map[string]any{"cached_tokens": 64},
+			"completion_tokens_details": map[string]any{"reasoning_tokens": 5},
+		}
+		writeSSE(t, w,
+			streamChunk(map[string]any{"role": "assistant", "content": "Hi"}, ""),
+			streamChunk(map[string]any{}, "stop"),
+			usageChunk,
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	responses := collect(t, m, userRequest("hello"), true)
+
+	streamOptions, _ := reqBody["stream_options"].(map[string]any)
+	if streamOptions["include_usage"] != true {
+		t.Errorf("Expected stream_options.include_usage=true, got %v", reqBody["stream_options"])
+	}
+
+	usage := responses[len(responses)-1].UsageMetadata
+	if usage == nil {
+		t.Fatal("Expected usage metadata on final response")
+	}
+	if usage.PromptTokenCount != 100 || usage.CandidatesTokenCount != 20 || usage.TotalTokenCount != 120 {
+		t.Errorf("Unexpected token counts: %+v", usage)
+	}
+	if usage.CachedContentTokenCount != 64 || usage.ThoughtsTokenCount != 5 {
+		t.Errorf("Unexpected token details: %+v", usage)
+	}
+
+	// Opt out for providers that reject stream_options
+	m = New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model", DisableStreamUsage: true})
+	collect(t, m, userRequest("hello"), true)
+	if _, ok := reqBody["stream_options"]; ok {
+		t.Errorf("Expected no stream_options, got %v", reqBody["stream_options"])
+	}
+}
+
+func TestToolChoice(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+		{Name: "get_weather"},
+		{Name: "get_time"},
+	}}}
+
+	tests := []struct {
+		name string
+		fcc  *genai.FunctionCallingConfig
+		want string
+	}{
+		{"auto", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}, `"auto"`},
+		{"validated", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeValidated}, `"auto"`},
+		{"none", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}, `"none"`},
+		{"any", &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}, `"required"`},
+		{"any single name", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{"get_time"},
+		}, `{"function":{"name":"get_time"},"type":"function"}`},
+		{"any several names", &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{"get_time", "get_weather"},
+		}, `{"allowed_tools":{"mode":"required","tools":[{"function":{"name":"get_time"},"type":"function"},{"function":{"name":"get_weather"},"type":"function"}]},"type":"allowed_tools"}`},
+	}
+
+	m := New(Config{ModelName: "test-model", DisableParallelToolCalls: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := m.buildChatCompletionParams(&model.LLMRequest{
+				Contents: userRequest("hi").Contents,
+				Config: &genai.GenerateContentConfig{
+					Tools:      tools,
+					ToolConfig: &genai.ToolConfig{FunctionCallingConfig: tt.fcc},
+				},
+			})
+			if err != nil {
+				t.Fatalf("buildChatCompletionParams failed: %v", err)
+			}
+
+			got, err := json.Marshal(params.ToolChoice)
+			if err != nil {
+				t.Fatalf("Failed to marshal tool choice: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected tool_choice %s, got %s", tt.want, got)
+			}
+			if params.ParallelToolCalls.Value != false || !params.ParallelToolCalls.Valid() {
+				t.Errorf("Expected parallel_tool_calls=false")
+			}
+		})
+	}
+
+	// Nothing is set when no tools are sent
+	params, err := m.buildChatCompletionParams(&model.LLMRequest{
+		Contents: userRequest("hi").Contents,
+		Config: &genai.GenerateContentConfig{
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: tests[0].fcc},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildChatCompletionParams failed: %v", err)
+	}
+	if params.ParallelToolCalls.Valid() {
+		t.Errorf("Expected parallel_tool_calls to be omitted without tools")
+	}
+}
+
+func TestToolChoiceUnknownTool(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}}
+
+	m := New(Config{ModelName: "test-model"})
+	for _, mode := range []genai.FunctionCallingConfigMode{genai.FunctionCallingConfigModeAny, genai.FunctionCallingConfigModeAuto} {
+		req := &model.LLMRequest{
+			Contents: userRequest("hi").Contents,
+			Config: &genai.GenerateContentConfig{
+				Tools: tools,
+				ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode:                 mode,
+					AllowedFunctionNames: []string{"get_time"},
+				}},
+			},
+		}
+		if _, err := m.buildChatCompletionParams(req); !errors.Is(err, ErrUnknownTool) || !strings.Contains(err.Error(), "get_time") {
+			t.Errorf("Mode %s: expected ErrUnknownTool naming get_time, got %v", mode, err)
+		}
+		if _, err := m.buildResponseParams(req); !errors.Is(err, ErrUnknownTool) {
+			t.Errorf("Mode %s: expected ErrUnknownTool from the Responses API, got %v", mode, err)
+		}
+	}
+}
+
+func TestSamplingAndLogprobs(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reqBody = nil
+		json.NewDecoder(r.Body).Decode(&reqBody)
+
+		logprobs := func(tokens ...any) map[string]any {
+			return map[string]any{"content": tokens, "refusal": []any{}}
+		}
+		token := func(text string, logprob float64) map[string]any {
+			return map[string]any{"token": text, "logprob": logprob, "bytes": []any{}, "top_logprobs": []any{
+				map[string]any{"token": text, "logprob": logprob, "bytes": []any{}},
+				map[string]any{"token": "other", "logprob": -3.0, "bytes": []any{}},
+			}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":     "chatcmpl-1",
+			"object": "chat.completion",
+			"model":  "test-model",
+			"choices": []any{
+				map[string]any{"index": 0, "finish_reason": "stop", "logprobs": logprobs(token("Hi", -0.5), token("!", -1.5)),
+					"message": map[string]any{"role": "assistant", "content": "Hi!"}},
+				map[string]any{"index": 1, "finish_reason": "stop", "logprobs": logprobs(token("Hey", -1.0)),
+					"message": map[string]any{"role": "assistant", "content": "Hey"}},
+			},
+		})
+	})
+
+	req := userRequest("hello")
+	req.Config = &genai.GenerateContentConfig{
+		Seed:             genai.Ptr[int32](42),
+		PresencePenalty:  genai.Ptr[float32](0.5),
+		FrequencyPenalty: genai.Ptr[float32](0.25),
+		CandidateCount:   2,
+		ResponseLogprobs: true,
+		Logprobs:         genai.Ptr[int32](2),
+	}
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	resp := collect(t, m, req, false)[0]
+
+	want := map[string]any{"seed": 42.0, "presence_penalty": 0.5, "frequency_penalty": 0.25, "n": 2.0, "logprobs": true, "top_logprobs": 2.0}
+	for key, value := range want {
+		if reqBody[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, reqBody[key])
+		}
+	}
+
+	if resp.Content.Parts[0].Text != "Hi!" || resp.AvgLogprobs != -1.0 {
+		t.Errorf("Unexpected first choice: text=%q avg=%v", resp.Content.Parts[0].Text, resp.AvgLogprobs)
+	}
+	if lp := resp.LogprobsResult; lp == nil || len(lp.ChosenCandidates) != 2 || lp.ChosenCandidates[1].Token != "!" || len(lp.TopCandidates[0].Candidates) != 2 {
+		t.Errorf("Unexpected logprobs: %+v", resp.LogprobsResult)
+	}
+
+	extra, _ := resp.CustomMetadata[CandidatesKey].([]*genai.Candidate)
+	if len(extra) != 1 || extra[0].Index != 1 || extra[0].Content.Parts[0].Text != "Hey" || extra[0].AvgLogprobs != -1.0 {
+		t.Errorf("Expected second choice as extra candidate, got %+v", resp.CustomMetadata)
+	}
+}
@@ -0,0 +1,532 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/media"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// API selects which OpenAI endpoint the Model talks to.
+type API string
+
+const (
+	// APIChatCompletions uses /v1/chat/completions. Supported by every
+	// OpenAI-compatible provider. This is the default.
+	APIChatCompletions API = "chat_completions"
+	// APIResponses uses /v1/responses, required for reasoning items,
+	// encrypted reasoning carry-over and built-in tools.
+	APIResponses API = "responses"
+)
+
+var (
+	ErrResponseFailed = errors.New("OpenAI response failed")
+)
+
+// reasoningSignature is the payload stored in genai.Part.ThoughtSignature for
+// reasoning items, so they can be sent back on the next turn.
+type reasoningSignature struct {
+	ID               string `json:"id"`
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+}
+
+// generateResponses sends a non-streaming Responses API request and yields a single response.
+func (m *Model) generateResponses(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		params, err := m.buildResponseParams(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		resp, err := m.client.Responses.New(ctx, params)
+		if err != nil {
+			yield(nil, classifyError(err))
+			return
+		}
+
+		llmResp, err := m.convertResponsesOutput(resp)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		yield(llmResp, nil)
+	}
+}
+
+// generateResponsesStream sends a streaming Responses API request and yields
+// partial responses as events arrive, followed by a final aggregated response.
+func (m *Model) generateResponsesStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		params, err := m.buildResponseParams(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		stream := m.client.Responses.NewStreaming(ctx, params)
+		var responseID string
+		toolCalls := newToolCallStream(func(id string, index int64, name string) string {
+			return m.resolveToolCallID(id, responseID, index, name)
+		})
+
+		var final *responses.Response
+
+		for stream.Next() {
+			event := stream.Current()
+
+			var partials []*model.LLMResponse
+			switch event.Type {
+			case "response.created":
+				responseID = event.Response.ID
+			case "response.output_text.delta":
+				if event.Delta != "" {
+					partials = append(partials, partialTextResponse(&genai.Part{Text: event.Delta}))
+				}
+			case "response.reasoning_summary_text.delta":
+				if event.Delta != "" && m.includeThoughts {
+					partials = append(partials, partialTextResponse(&genai.Part{Text: event.Delta, Thought: true}))
+				}
+			case "response.output_item.added":
+				if event.Item.Type == "function_call" && m.streamToolCalls {
+					partials = toolCalls.add(event.OutputIndex, event.Item.CallID, event.Item.Name, "")
+				}
+			case "response.function_call_arguments.delta":
+				if m.streamToolCalls {
+					partials = toolCalls.add(event.OutputIndex, "", "", event.Delta)
+				}
+			case "response.function_call_arguments.done":
+				if !m.streamToolCalls {
+					break
+				}
+				if resp := toolCalls.complete(event.OutputIndex); resp != nil {
+					partials = append(partials, resp)
+				}
+			case "response.completed", "response.incomplete":
+				final = &event.Response
+			case "response.failed":
+				err := fmt.Errorf("%w: %s", ErrResponseFailed, event.Response.Error.Message)
+				yield(nil, classifyErrorCode(err, string(event.Response.Error.Code)))
+				return
+			case "error":
+				err := fmt.Errorf("%w: %s (%s)", ErrResponseFailed, event.Message, event.Code)
+				yield(nil, classifyErrorCode(err, event.Code))
+				return
+			}
+
+			for _, llmResp := range partials {
+				if !yield(llmResp, nil) {
+					return
+				}
+			}
+		}
+
+		if err := stream.Err(); err != nil {
+			yield(nil, classifyError(err))
+			return
+		}
+
+		if final == nil {
+			yield(nil, fmt.Errorf("%w: stream ended without a completed response", ErrResponseFailed))
+			return
+		}
+
+		llmResp, err := m.convertResponsesOutput(final)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		yield(llmResp, nil)
+	}
+}
+
+// partialTextResponse wraps a streamed text or thought part into a partial LLMResponse.
+func partialTextResponse(part *genai.Part) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content: &genai.Content{
+			Role:  genai.RoleModel,
+			Parts: []*genai.Part{part},
+		},
+		Partial:      true,
+		TurnComplete: false,
+	}
+}
+
+// buildResponseParams converts an LLMRequest into Responses API parameters.
+// Requests are stateless (store=false): the full history is sent every turn,
+// with reasoning items carried over through their encrypted content.
+func (m *Model) buildResponseParams(req *model.LLMRequest) (responses.ResponseNewParams, error) {
+	var input responses.ResponseInputParam
+
+	for _, content := range req.Contents {
+		items, err := m.convertContentToInputItems(content)
+		if err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+		input = append(input, items...)
+	}
+
+	params := responses.ResponseNewParams{
+		Model: shared.ResponsesModel(m.modelName),
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: input},
+		Store: openai.Bool(false),
+	}
+
+	if req.Config != nil {
+		if err := m.applyResponsesConfig(&params, req.Config); err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+	}
+
+	params.Tools = append(params.Tools, m.builtinTools...)
+
+	return params, nil
+}
+
+// applyResponsesConfig applies optional generation settings to Responses API params.
+func (m *Model) applyResponsesConfig(params *responses.ResponseNewParams, cfg *genai.GenerateContentConfig) error {
+	if cfg.SystemInstruction != nil {
+		if text := extractText(cfg.SystemInstruction); text != "" {
+			params.Instructions = openai.String(text)
+		}
+	}
+	if cfg.Temperature != nil {
+		params.Temperature = openai.Float(float64(*cfg.Temperature))
+	}
+	if cfg.MaxOutputTokens > 0 {
+		params.MaxOutputTokens = openai.Int(int64(cfg.MaxOutputTokens))
+	}
+	if cfg.TopP != nil {
+		params.TopP = openai.Float(float64(*cfg.TopP))
+	}
+
+	// Reasoning effort, summaries and encrypted carry-over
+	if cfg.ThinkingConfig != nil {
+		params.Reasoning.Effort = convertThinkingLevel(cfg.ThinkingConfig.ThinkingLevel)
+		if m.includeThoughts {
+			params.Reasoning.Summary = shared.ReasoningSummaryAuto
+		}
+		params.Include = append(params.Include, responses.ResponseIncludableReasoningEncryptedContent)
+	}
+
+	// JSON mode
+	if cfg.ResponseMIMEType == "application/json" {
+		params.Text.Format = responses.ResponseFormatTextConfigUnionParam{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	}
+
+	// Structured output with schema
+	schema, err := m.buildResponseSchema(cfg)
+	if err != nil {
+		return err
+	}
+	if schema != nil {
+		format := &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   "response",
+			Schema: schema,
+			Strict: openai.Bool(!m.disableStrictSchema),
+		}
+		if description, _ := schema["description"].(string); description != "" {
+			format.Description = openai.String(description)
+		}
+		params.Text.Format = responses.ResponseFormatTextConfigUnionParam{OfJSONSchema: format}
+	}
+
+	// Function tools
+	for _, genaiTool := range cfg.Tools {
+		if genaiTool == nil {
+			continue
+		}
+		for _, funcDecl := range genaiTool.FunctionDeclarations {
+			schema := funcDecl.ParametersJsonSchema
+			if schema == nil {
+				schema = funcDecl.Parameters
+			}
+			funcParams, err := convertToFunctionParams(schema)
+			if err != nil {
+				return fmt.Errorf("failed to convert parameters of tool %q: %w", funcDecl.Name, err)
+			}
+			// Strict mode is opt-in here, as with chat completions tools
+			tool := responses.ToolParamOfFunction(funcDecl.Name, funcParams, false)
+			tool.OfFunction.Description = openai.String(funcDecl.Description)
+			params.Tools = append(params.Tools, tool)
+		}
+	}
+
+	if len(params.Tools) > 0 {
+		choice, ok, err := resolveToolChoice(cfg.ToolConfig, cfg.Tools)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params.ToolChoice = convertResponsesToolChoice(choice)
+		}
+		if m.disableParallelToolCalls {
+			params.ParallelToolCalls = openai.Bool(false)
+		}
+	}
+
+	return nil
+}
+
+// convertResponsesToolChoice maps a toolChoice to the Responses API tool_choice.
+// Unlike chat completions, allowed_tools entries are flat ({"type", "name"}).
+func convertResponsesToolChoice(choice toolChoice) responses.ResponseNewParamsToolChoiceUnion {
+	if choice.mode == "required" && len(choice.names) == 1 {
+		return responses.ResponseNewParamsToolChoiceUnion{
+			OfFunctionTool: &responses.ToolChoiceFunctionParam{Name: choice.names[0]},
+		}
+	}
+	if choice.mode != "none" && len(choice.names) > 0 {
+		tools := make([]map[string]any, 0, len(choice.names))
+		for _, name := range choice.names {
+			tools = append(tools, map[string]any{"type": "function", "name": name})
+		}
+		return responses.ResponseNewParamsToolChoiceUnion{
+			OfAllowedTools: &responses.ToolChoiceAllowedParam{
+				Mode:  responses.ToolChoiceAllowedMode(choice.mode),
+				Tools: tools,
+			},
+		}
+	}
+	return responses.ResponseNewParamsToolChoiceUnion{
+		OfToolChoiceMode: openai.Opt(responses.ToolChoiceOptions(choice.mode)),
+	}
+}
+
+// convertContentToInputItems converts a genai.Content into Responses API input items.
+// Handles text, images, reasoning, function calls, and function responses.
+func (m *Model) convertContentToInputItems(content *genai.Content) ([]responses.ResponseInputItemUnionParam, error) {
+	var items []responses.ResponseInputItemUnionParam
+	var parts responses.ResponseInputMessageContentListParam
+	var texts []string
+
+	role := convertRole(content.Role)
+
+	// flushMessage emits the text and images collected so far as a message item,
+	// keeping the original ordering relative to function calls.
+	flushMessage := func() {
+		if len(texts) == 0 && len(parts) == 0 {
+			return
+		}
+		switch role {
+		case "assistant":
+			items = append(items, responses.ResponseInputItemParamOfMessage(joinTexts(texts), responses.EasyInputMessageRoleAssistant))
+		case "system":
+			items = append(items, responses.ResponseInputItemParamOfMessage(joinTexts(texts), responses.EasyInputMessageRoleSystem))
+		default:
+			var list responses.ResponseInputMessageContentListParam
+			for _, text := range texts {
+				list = append(list, responses.ResponseInputContentUnionParam{
+					OfInputText: &responses.ResponseInputTextParam{Text: text},
+				})
+			}
+			list = append(list, parts...)
+			items = append(items, responses.ResponseInputItemParamOfMessage(list, responses.EasyInputMessageRoleUser))
+		}
+		texts, parts = nil, nil
+	}
+
+	for _, part := range content.Parts {
+		switch {
+		case part.Thought:
+			// Reasoning items are only replayable through their encrypted content
+			if item := convertThoughtToReasoningItem(part); item != nil {
+				flushMessage()
+				items = append(items, *item)
+			}
+
+		case part.FunctionResponse != nil:
+			flushMessage()
+			responseJSON, err := json.Marshal(part.FunctionResponse.Response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal function response: %w", err)
+			}
+			callID := m.normalizeToolCallID(part.FunctionResponse.ID)
+			items = append(items, responses.ResponseInputItemParamOfFunctionCallOutput(callID, string(responseJSON)))
+
+		case part.FunctionCall != nil:
+			flushMessage()
+			argsJSON, err := json.Marshal(part.FunctionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal function args: %w", err)
+			}
+			callID := m.normalizeToolCallID(part.FunctionCall.ID)
+			items = append(items, responses.ResponseInputItemParamOfFunctionCall(string(argsJSON), callID, part.FunctionCall.Name))
+
+		case part.Text != "":
+			texts = append(texts, part.Text)
+
+		case media.IsPart(part):
+			// Only user messages can carry media
+			if role != "user" {
+				return nil, fmt.Errorf("%w: media in %s messages", ErrUnsupportedPart, role)
+			}
+			input, err := convertMediaInput(part)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, input)
+		}
+	}
+	flushMessage()
+
+	return items, nil
+}
+
+// convertThoughtToReasoningItem rebuilds a reasoning input item from a thought
+// part produced by convertResponsesOutput. Returns nil when the part carries no
+// encrypted reasoning, since stateless requests cannot reference it otherwise.
+func convertThoughtToReasoningItem(part *genai.Part) *responses.ResponseInputItemUnionParam {
+	if len(part.ThoughtSignature) == 0 {
+		return nil
+	}
+	var sig reasoningSignature
+	if err := json.Unmarshal(part.ThoughtSignature, &sig); err != nil || sig.ID == "" || sig.EncryptedContent == "" {
+		return nil
+	}
+
+	summary := []responses.ResponseReasoningItemSummaryParam{}
+	if part.Text != "" {
+		summary = append(summary, responses.ResponseReasoningItemSummaryParam{Text: part.Text})
+	}
+
+	item := responses.ResponseInputItemParamOfReasoning(sig.ID, summary)
+	item.OfReasoning.EncryptedContent = openai.String(sig.EncryptedContent)
+	return &item
+}
+
+// convertResponsesOutput transforms a Responses API response into an LLMResponse.
+func (m *Model) convertResponsesOutput(resp *responses.Response) (*model.LLMResponse, error) {
+	if len(resp.Output) == 0 {
+		return nil, ErrNoChoicesInResponse
+	}
+
+	content := &genai.Content{
+		Role:  genai.RoleModel,
+		Parts: []*genai.Part{},
+	}
+
+	for i, item := range resp.Output {
+		switch item.Type {
+		case "reasoning":
+			if part := m.convertReasoningItem(item); part != nil {
+				content.Parts = append(content.Parts, part)
+			}
+
+		case "message":
+			for _, c := range item.Content {
+				switch c.Type {
+				case "output_text":
+					if c.Text != "" {
+						content.Parts = append(content.Parts, &genai.Part{Text: c.Text})
+					}
+				case "refusal":
+					if c.Refusal != "" {
+						content.Parts = append(content.Parts, &genai.Part{Text: c.Refusal})
+					}
+				}
+			}
+
+		case "function_call":
+			content.Parts = append(content.Parts, &genai.Part{
+				FunctionCall: &genai.FunctionCall{
+					ID:   m.resolveToolCallID(item.CallID, resp.ID, int64(i), item.Name),
+					Name: item.Name,
+					Args: parseJSONArgs(item.Arguments),
+				},
+			})
+		}
+	}
+
+	return &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: convertResponsesUsage(resp.Usage),
+		FinishReason:  convertResponseStatus(resp),
+		TurnComplete:  true,
+	}, nil
+}
+
+// convertReasoningItem maps a reasoning output item to a thought part. The
+// summary text is only kept when thoughts are included, but the signature is
+// always kept so the reasoning can be carried over to the next turn.
+func (m *Model) convertReasoningItem(item responses.ResponseOutputItemUnion) *genai.Part {
+	var summaries []string
+	for _, s := range item.Summary {
+		if s.Text != "" {
+			summaries = append(summaries, s.Text)
+		}
+	}
+
+	part := &genai.Part{Thought: true}
+	if m.includeThoughts {
+		part.Text = joinTexts(summaries)
+	}
+	if item.EncryptedContent != "" {
+		sig, err := json.Marshal(reasoningSignature{ID: item.ID, EncryptedContent: item.EncryptedContent})
+		if err == nil {
+			part.ThoughtSignature = sig
+		}
+	}
+
+	if part.Text == "" && len(part.ThoughtSignature) == 0 {
+		return nil
+	}
+	return part
+}
+
+// convertResponsesUsage converts Responses API usage stats to genai format.
+func convertResponsesUsage(usage responses.ResponseUsage) *genai.GenerateContentResponseUsageMetadata {
+	if usage.TotalTokens == 0 {
+		return nil
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        int32(usage.InputTokens),
+		CachedContentTokenCount: int32(usage.InputTokensDetails.CachedTokens),
+		CandidatesTokenCount:    int32(usage.OutputTokens),
+		ThoughtsTokenCount:      int32(usage.OutputTokensDetails.ReasoningTokens),
+		TotalTokenCount:         int32(usage.TotalTokens),
+	}
+}
+
+// convertResponseStatus maps a Responses API status to genai format.
+func convertResponseStatus(resp *responses.Response) genai.FinishReason {
+	switch resp.Status {
+	case responses.ResponseStatusCompleted:
+		return genai.FinishReasonStop
+	case responses.ResponseStatusIncomplete:
+		switch resp.IncompleteDetails.Reason {
+		case "max_output_tokens":
+			return genai.FinishReasonMaxTokens
+		case "content_filter":
+			return genai.FinishReasonSafety
+		}
+		return genai.FinishReasonOther
+	default:
+		return genai.FinishReasonUnspecified
+	}
+}
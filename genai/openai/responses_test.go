@@ -0,0 +1,201 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// responsesBody is a minimal completed Responses API response with a
+// reasoning item, a message and a function call.
+func responsesBody() map[string]any {
+	return map[string]any{
+		"id":         "resp_1",
+		"object":     "response",
+		"created_at": 0,
+		"model":      "o4-mini",
+		"status":     "completed",
+		"output": []any{
+			map[string]any{
+				"type":              "reasoning",
+				"id":                "rs_1",
+				"summary":           []any{map[string]any{"type": "summary_text", "text": "Thinking about it"}},
+				"encrypted_content": "gAAAA",
+			},
+			map[string]any{
+				"type":   "message",
+				"id":     "msg_1",
+				"role":   "assistant",
+				"status": "completed",
+				"content": []any{
+					map[string]any{"type": "output_text", "text": "Checking the weather", "annotations": []any{}},
+				},
+			},
+			map[string]any{
+				"type":      "function_call",
+				"id":        "fc_1",
+				"call_id":   "call_1",
+				"name":      "get_weather",
+				"arguments": `{"city":"Paris"}`,
+			},
+		},
+		"usage": map[string]any{
+			"input_tokens":          10,
+			"input_tokens_details":  map[string]any{"cached_tokens": 4},
+			"output_tokens":         20,
+			"output_tokens_details": map[string]any{"reasoning_tokens": 8},
+			"total_tokens":          30,
+		},
+	}
+}
+
+func TestResponsesAPI(t *testing.T) {
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" {
+			t.Errorf("Expected /responses, got %s", r.URL.Path)
+		}
+		reqBody = nil
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(responsesBody())
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "o4-mini", API: APIResponses, IncludeThoughts: true})
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("Weather in Paris?", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief", genai.RoleUser),
+			ThinkingConfig:    &genai.ThinkingConfig{ThinkingLevel: genai.ThinkingLevelLow},
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:                 "get_weather",
+				ParametersJsonSchema: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+			}}}},
+		},
+	}
+	responses := collect(t, m, req, false)
+
+	if reqBody["instructions"] != "Be brief" {
+		t.Errorf("Expected instructions, got %v", reqBody["instructions"])
+	}
+	if reqBody["store"] != false {
+		t.Errorf("Expected store=false, got %v", reqBody["store"])
+	}
+	if include, _ := reqBody["include"].([]any); len(include) != 1 || include[0] != "reasoning.encrypted_content" {
+		t.Errorf("Expected encrypted reasoning to be included, got %v", reqBody["include"])
+	}
+	if tools, _ := reqBody["tools"].([]any); len(tools) != 1 {
+		t.Errorf("Expected 1 tool, got %v", reqBody["tools"])
+	}
+
+	parts := responses[0].Content.Parts
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	if !parts[0].Thought || parts[0].Text != "Thinking about it" || len(parts[0].ThoughtSignature) == 0 {
+		t.Errorf("Expected signed thought part, got %+v", parts[0])
+	}
+	if parts[1].Text != "Checking the weather" {
+		t.Errorf("Expected text part, got %+v", parts[1])
+	}
+	if fc := parts[2].FunctionCall; fc == nil || fc.ID != "call_1" || fc.Args["city"] != "Paris" {
+		t.Errorf("Expected function call, got %+v", parts[2].FunctionCall)
+	}
+
+	usage := responses[0].UsageMetadata
+	if usage.CachedContentTokenCount != 4 || usage.ThoughtsTokenCount != 8 || usage.TotalTokenCount != 30 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+
+	// Send the turn back and check the reasoning item is carried over
+	req.Contents = append(req.Contents, responses[0].Content, &genai.Content{
+		Role: genai.RoleUser,
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			ID:       "call_1",
+			Name:     "get_weather",
+			Response: map[string]any{"temp": 20},
+		}}},
+	})
+	collect(t, m, req, false)
+
+	input, _ := reqBody["input"].([]any)
+	var types []string
+	for _, item := range input {
+		itemMap := item.(map[string]any)
+		itemType, _ := itemMap["type"].(string)
+		if itemType == "" {
+			itemType = fmt.Sprintf("message:%v", itemMap["role"])
+		}
+		types = append(types, itemType)
+		if itemType == "reasoning" && (itemMap["id"] != "rs_1" || itemMap["encrypted_content"] != "gAAAA") {
+			t.Errorf("Unexpected reasoning item: %v", itemMap)
+		}
+	}
+	want := []string{"message:user", "reasoning", "message:assistant", "function_call", "function_call_output"}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Errorf("Expected input items %v, got %v", want, types)
+	}
+}
+
+func TestResponsesAPIStream(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		completed := responsesBody()
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []map[string]any{
+			{"type": "response.reasoning_summary_text.delta", "delta": "Thinking"},
+			{"type": "response.output_text.delta", "delta": "Checking "},
+			{"type": "response.output_text.delta", "delta": "the weather"},
+			{"type": "response.output_item.added", "output_index": 2, "item": map[string]any{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": ""}},
+			{"type": "response.function_call_arguments.delta", "output_index": 2, "delta": `{"city":"Paris"}`},
+			{"type": "response.function_call_arguments.done", "output_index": 2, "arguments": `{"city":"Paris"}`},
+			{"type": "response.completed", "response": completed},
+		}
+		for i, event := range events {
+			event["sequence_number"] = i
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], data)
+		}
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "o4-mini", API: APIResponses, IncludeThoughts: true, StreamToolCalls: true})
+	responses := collect(t, m, userRequest("Weather in Paris?"), true)
+
+	if len(responses) != 7 {
+		t.Fatalf("Expected 7 responses, got %d", len(responses))
+	}
+	if !responses[0].Content.Parts[0].Thought {
+		t.Errorf("Expected partial thought first")
+	}
+	if responses[1].Content.Parts[0].Text != "Checking " {
+		t.Errorf("Expected partial text, got %+v", responses[1].Content.Parts[0])
+	}
+	if delta, _ := responses[5].CustomMetadata[toolcall.DeltaKey].(toolcall.Delta); !delta.Done || delta.Args["city"] != "Paris" {
+		t.Errorf("Expected completed tool call delta, got %+v", delta)
+	}
+
+	final := responses[6]
+	if final.Partial || !final.TurnComplete || len(final.Content.Parts) != 3 {
+		t.Errorf("Unexpected final response: %+v", final)
+	}
+}
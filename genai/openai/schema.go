@@ -0,0 +1,221 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/achetronic/adk-utils-go/genai/internal/jsonschema"
+	"github.com/openai/openai-go/v3/shared"
+	"google.golang.org/genai"
+)
+
+// strictUnsupportedKeywords are dropped when normalizing for OpenAI strict mode,
+// which rejects schemas that use them.
+var strictUnsupportedKeywords = []string{
+	"default",
+	"minProperties", "maxProperties", "patternProperties",
+	"unevaluatedProperties", "propertyNames",
+	"unevaluatedItems", "contains", "minContains", "maxContains", "uniqueItems",
+}
+
+// convertToFunctionParams converts various parameter types to OpenAI format.
+// OpenAI requires object schemas to have a "properties" field, even if empty.
+func convertToFunctionParams(params any) (shared.FunctionParameters, error) {
+	schema, err := jsonschema.ToMap(params)
+	if err != nil || schema == nil {
+		return nil, err
+	}
+
+	// OpenAI requires "properties" for object types
+	ensureObjectProperties(schema)
+	finalizeSchema(schema)
+
+	return shared.FunctionParameters(schema), nil
+}
+
+// buildResponseSchema converts the structured output schema of a request
+// (ResponseJsonSchema or ResponseSchema) to JSON schema, normalized for strict
+// mode unless it is disabled. Returns nil when the request has no schema.
+func (m *Model) buildResponseSchema(cfg *genai.GenerateContentConfig) (map[string]any, error) {
+	var schema map[string]any
+	var err error
+
+	switch {
+	case cfg.ResponseJsonSchema != nil:
+		schema, err = jsonschema.ToMap(cfg.ResponseJsonSchema)
+	case cfg.ResponseSchema != nil:
+		schema, err = jsonschema.Convert(cfg.ResponseSchema)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert response schema: %w", err)
+	}
+
+	ensureObjectProperties(schema)
+	if !m.disableStrictSchema {
+		if err := normalizeStrictSchema(schema); err != nil {
+			return nil, fmt.Errorf("failed to convert response schema: %w", err)
+		}
+	}
+	finalizeSchema(schema)
+
+	return schema, nil
+}
+
+// ensureObjectProperties recursively ensures all object schemas have a properties field.
+func ensureObjectProperties(schema map[string]any) {
+	if schema == nil {
+		return
+	}
+
+	// If type is "object" and no properties, add empty properties
+	if jsonschema.HasType(schema, "object") {
+		if _, hasProps := schema["properties"]; !hasProps {
+			schema["properties"] = map[string]any{}
+		}
+	}
+
+	jsonschema.ForEachSubschema(schema, ensureObjectProperties)
+}
+
+// normalizeStrictSchema rewrites a schema in place to follow OpenAI's strict
+// mode rules: every object lists all of its properties as required and sets
+// additionalProperties to false, and originally optional properties become
+// nullable instead. The root must be an object, and schemas strict mode cannot
+// express (open objects, oneOf next to anyOf) are rejected rather than changed.
+func normalizeStrictSchema(schema map[string]any) error {
+	if !jsonschema.HasType(schema, "object") {
+		return fmt.Errorf("%w: strict mode requires an object schema at the root", ErrInvalidSchema)
+	}
+	return normalizeStrictNode(schema)
+}
+
+// normalizeStrictNode applies the strict mode rules to a schema and its subschemas.
+func normalizeStrictNode(schema map[string]any) error {
+	for _, keyword := range strictUnsupportedKeywords {
+		delete(schema, keyword)
+	}
+
+	// Strict mode supports anyOf but not oneOf
+	if oneOf, ok := schema["oneOf"]; ok {
+		if _, hasAnyOf := schema["anyOf"]; hasAnyOf {
+			return fmt.Errorf("%w: strict mode does not support oneOf alongside anyOf", ErrInvalidSchema)
+		}
+		schema["anyOf"] = oneOf
+		delete(schema, "oneOf")
+	}
+
+	if props, ok := schema["properties"].(map[string]any); ok {
+		if additional, ok := schema["additionalProperties"]; ok && additional != false {
+			return fmt.Errorf("%w: strict mode requires additionalProperties to be false, got %v", ErrInvalidSchema, additional)
+		}
+
+		required := make(map[string]bool)
+		for _, name := range jsonschema.StringList(schema["required"]) {
+			required[name] = true
+		}
+		for name, prop := range props {
+			if propMap, ok := prop.(map[string]any); ok && !required[name] {
+				jsonschema.MakeNullable(propMap)
+			}
+		}
+		schema["required"] = orderedKeys(props, jsonschema.StringList(schema["propertyOrdering"]))
+		schema["additionalProperties"] = false
+	}
+
+	var err error
+	jsonschema.ForEachSubschema(schema, func(sub map[string]any) {
+		if err == nil {
+			err = normalizeStrictNode(sub)
+		}
+	})
+	return err
+}
+
+// finalizeSchema prepares a converted schema for sending. Gemini's
+// propertyOrdering keyword is not JSON schema, so it is removed and applied to
+// the encoded key order of "properties" instead (OpenAI generates keys in
+// schema order, while Go sorts map keys when encoding).
+func finalizeSchema(schema map[string]any) {
+	jsonschema.ForEachSubschema(schema, finalizeSchema)
+
+	ordering := jsonschema.StringList(schema["propertyOrdering"])
+	delete(schema, "propertyOrdering")
+
+	if props, ok := schema["properties"].(map[string]any); ok && len(ordering) > 0 {
+		schema["properties"] = orderedProperties{
+			keys:   orderedKeys(props, ordering),
+			values: props,
+		}
+	}
+}
+
+// orderedProperties encodes schema properties as a JSON object with a fixed key order.
+type orderedProperties struct {
+	keys   []string
+	values map[string]any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p orderedProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueJSON, err := json.Marshal(p.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(valueJSON)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderedKeys returns the keys of props, first in the given ordering, then the
+// remaining ones sorted alphabetically.
+func orderedKeys(props map[string]any, ordering []string) []string {
+	keys := make([]string, 0, len(props))
+	seen := make(map[string]bool, len(props))
+	for _, key := range ordering {
+		if _, ok := props[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range props {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestNormalizeStrictSchema(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "default": "x"},
+			"email": map[string]any{"type": "string"},
+			"kind":  map[string]any{"enum": []any{"a", "b"}},
+			"addr":  map[string]any{"$ref": "#/$defs/addr"},
+			"pet": map[string]any{"oneOf": []any{
+				map[string]any{"type": "object", "properties": map[string]any{"n": map[string]any{"type": "string"}}},
+			}},
+		},
+		"required":         []any{"name"},
+		"propertyOrdering": []any{"name", "email"},
+		"$defs": map[string]any{
+			"addr": map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		},
+	}
+	if err := normalizeStrictSchema(schema); err != nil {
+		t.Fatalf("normalizeStrictSchema failed: %v", err)
+	}
+
+	if fmt.Sprint(schema["required"]) != "[name email addr kind pet]" || schema["additionalProperties"] != false {
+		t.Errorf("Unexpected root: required=%v additionalProperties=%v", schema["required"], schema["additionalProperties"])
+	}
+	props := schema["properties"].(map[string]any)
+	if name := props["name"].(map[string]any); name["type"] != "string" || name["default"] != nil {
+		t.Errorf("Expected required name unchanged without default, got %v", name)
+	}
+	if email := props["email"].(map[string]any); fmt.Sprint(email["type"]) != "[string null]" {
+		t.Errorf("Expected optional email to be nullable, got %v", email)
+	}
+	if kind := props["kind"].(map[string]any); fmt.Sprint(kind["enum"]) != "[a b <nil>]" {
+		t.Errorf("Expected nullable enum, got %v", kind)
+	}
+	if addr := props["addr"].(map[string]any); addr["$ref"] != nil || len(addr["anyOf"].([]any)) != 2 {
+		t.Errorf("Expected wrapped $ref, got %v", addr)
+	}
+	pet := props["pet"].(map[string]any)
+	anyOf, ok := pet["anyOf"].([]any)
+	if !ok || pet["oneOf"] != nil {
+		t.Fatalf("Expected oneOf to become anyOf, got %v", pet)
+	}
+	if variant := anyOf[0].(map[string]any); variant["additionalProperties"] != false || fmt.Sprint(variant["required"]) != "[n]" {
+		t.Errorf("Expected nested object normalized, got %v", variant)
+	}
+	if def := schema["$defs"].(map[string]any)["addr"].(map[string]any); def["additionalProperties"] != false {
+		t.Errorf("Expected $defs normalized, got %v", def)
+	}
+
+	if err := normalizeStrictSchema(map[string]any{"type": "array"}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Expected ErrInvalidSchema for non-object root, got %v", err)
+	}
+
+	// Schemas strict mode cannot express are rejected
+	invalid := map[string]map[string]any{
+		"open object": {"type": "object", "properties": map[string]any{
+			"tags": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		}},
+		"additionalProperties true": {"type": "object", "additionalProperties": true},
+		"oneOf and anyOf": {"type": "object", "properties": map[string]any{
+			"pet": map[string]any{"oneOf": []any{map[string]any{"type": "string"}}, "anyOf": []any{map[string]any{"type": "integer"}}},
+		}},
+	}
+	for name, schema := range invalid {
+		ensureObjectProperties(schema)
+		if err := normalizeStrictSchema(schema); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: expected ErrInvalidSchema, got %v", name, err)
+		}
+	}
+}
+
+func TestStructuredOutputSchema(t *testing.T) {
+	var rawBody string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		rawBody = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-1",
+			"object":  "chat.completion",
+			"model":   "gpt-4o",
+			"choices": []any{map[string]any{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": "{}"}}},
+		})
+	})
+
+	req := userRequest("hi")
+	req.Config = &genai.GenerateContentConfig{
+		ResponseSchema: &genai.Schema{
+			Type:        genai.TypeObject,
+			Description: "A person",
+			Properties: map[string]*genai.Schema{
+				"zeta":  {Type: genai.TypeString},
+				"alpha": {Type: genai.TypeInteger},
+			},
+			PropertyOrdering: []string{"zeta", "alpha"},
+		},
+		Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:       "lookup",
+			Parameters: &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{"q": {Type: genai.TypeString}}},
+		}}}},
+	}
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "gpt-4o"})
+	collect(t, m, req, false)
+
+	// Property order survives encoding and the genai-only keyword is dropped
+	if !strings.Contains(rawBody, `"properties":{"zeta":`) || strings.Contains(rawBody, "propertyOrdering") {
+		t.Errorf("Expected ordered properties without propertyOrdering, got %s", rawBody)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal([]byte(rawBody), &body); err != nil {
+		t.Fatalf("Failed to decode request body: %v", err)
+	}
+	jsonSchema := body["response_format"].(map[string]any)["json_schema"].(map[string]any)
+	if jsonSchema["strict"] != true || jsonSchema["description"] != "A person" {
+		t.Errorf("Unexpected json_schema: %v", jsonSchema)
+	}
+	schema := jsonSchema["schema"].(map[string]any)
+	if fmt.Sprint(schema["required"]) != "[zeta alpha]" || schema["additionalProperties"] != false {
+		t.Errorf("Expected strict-normalized schema, got %v", schema)
+	}
+	tool := body["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)
+	if q := tool["parameters"].(map[string]any)["properties"].(map[string]any)["q"].(map[string]any); q["type"] != "string" {
+		t.Errorf("Expected lowercase JSON schema types, got %v", q)
+	}
+
+	// Non-strict mode sends the schema as-is
+	m = New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "gpt-4o", DisableStrictSchema: true})
+	collect(t, m, req, false)
+	if !strings.Contains(rawBody, `"strict":false`) || strings.Contains(rawBody, `"additionalProperties"`) {
+		t.Errorf("Expected non-strict schema, got %s", rawBody)
+	}
+
+	// Conversion failures are reported
+	req.Config.ResponseSchema.Properties["bad"] = &genai.Schema{Type: "DATE"}
+	for _, err := range m.GenerateContent(t.Context(), req, false) {
+		if !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Expected ErrInvalidSchema, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"strings"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// streamingToolCall holds the state of a single tool call being streamed.
+type streamingToolCall struct {
+	index     int64
+	id        string
+	name      string
+	arguments strings.Builder
+	done      bool
+}
+
+// toolCallStream tracks streamed tool calls by their tool-call index.
+type toolCallStream struct {
+	calls map[int64]*streamingToolCall
+	order []int64
+
+	// resolveID maps the provider's tool-call ID (possibly empty) to the ID
+	// exposed in FunctionCall parts and deltas.
+	resolveID func(id string, index int64, name string) string
+}
+
+// newToolCallStream creates an empty tool call tracker.
+func newToolCallStream(resolveID func(id string, index int64, name string) string) *toolCallStream {
+	return &toolCallStream{
+		calls:     make(map[int64]*streamingToolCall),
+		resolveID: resolveID,
+	}
+}
+
+// update folds the tool-call deltas of a chunk into the tracked state and
+// returns the partial responses to yield. A call is complete once a call with
+// a higher index starts or the choice reports a finish reason.
+func (s *toolCallStream) update(choice openai.ChatCompletionChunkChoice) []*model.LLMResponse {
+	var responses []*model.LLMResponse
+
+	for _, delta := range choice.Delta.ToolCalls {
+		// Some providers (e.g. Bedrock) send -1 for single tool calls
+		index := max(delta.Index, 0)
+		responses = append(responses, s.add(index, delta.ID, delta.Function.Name, delta.Function.Arguments)...)
+	}
+
+	if choice.FinishReason != "" {
+		responses = append(responses, s.finish()...)
+	}
+
+	return responses
+}
+
+// add records a delta for the call at the given index and returns the partial
+// responses to yield. Starting a new call completes those with lower indices.
+func (s *toolCallStream) add(index int64, id, name, fragment string) []*model.LLMResponse {
+	var responses []*model.LLMResponse
+
+	call, exists := s.calls[index]
+	if !exists {
+		responses = append(responses, s.completeBefore(index)...)
+		call = &streamingToolCall{index: index}
+		s.calls[index] = call
+		s.order = append(s.order, index)
+	}
+	if call.done {
+		return responses
+	}
+
+	call.name += name
+	// Providers send the ID with the first delta, if at all. Generated IDs
+	// depend on the name, so they wait for it.
+	if id != "" || (call.id == "" && call.name != "") {
+		call.id = s.resolveID(id, index, call.name)
+	}
+	call.arguments.WriteString(fragment)
+
+	return append(responses, call.partialResponse(fragment))
+}
+
+// complete marks the call at the given index as complete. Returns nil if the
+// call is unknown or was already completed.
+func (s *toolCallStream) complete(index int64) *model.LLMResponse {
+	call, exists := s.calls[index]
+	if !exists || call.done {
+		return nil
+	}
+	call.done = true
+	return call.completeResponse()
+}
+
+// finish marks every pending call as complete and returns their final partials.
+func (s *toolCallStream) finish() []*model.LLMResponse {
+	return s.completeBefore(-1)
+}
+
+// completeBefore completes pending calls with an index lower than the given one.
+// A negative index completes all pending calls.
+func (s *toolCallStream) completeBefore(index int64) []*model.LLMResponse {
+	var responses []*model.LLMResponse
+	for _, i := range s.order {
+		call := s.calls[i]
+		if call.done || (index >= 0 && i >= index) {
+			continue
+		}
+		call.done = true
+		responses = append(responses, call.completeResponse())
+	}
+	return responses
+}
+
+// partialResponse builds a partial response carrying the latest argument fragment.
+func (c *streamingToolCall) partialResponse(fragment string) *model.LLMResponse {
+	return c.response(toolcall.Delta{
+		Index:          int(c.index),
+		ID:             c.id,
+		Name:           c.name,
+		ArgumentsDelta: fragment,
+	})
+}
+
+// completeResponse builds the partial response announcing that the call's
+// arguments are complete, with the parsed arguments attached.
+func (c *streamingToolCall) completeResponse() *model.LLMResponse {
+	return c.response(toolcall.Delta{
+		Index: int(c.index),
+		ID:    c.id,
+		Name:  c.name,
+		Done:  true,
+		Args:  parseJSONArgs(c.arguments.String()),
+	})
+}
+
+// response wraps a toolcall.Delta into a partial LLMResponse. The content has
+// no parts, so ADK flows forward the event without executing anything.
+func (c *streamingToolCall) response(delta toolcall.Delta) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:        &genai.Content{Role: genai.RoleModel},
+		CustomMetadata: map[string]any{toolcall.DeltaKey: delta},
+		Partial:        true,
+		TurnComplete:   false,
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Constants of the token estimate, following OpenAI's guidance for chat
+// models: each message costs a few tokens of framing, and every reply is
+// primed with a few more.
+const (
+	tokensPerMessage   = 3
+	replyPrimingTokens = 3
+	// charsPerToken is the average number of ASCII characters per token.
+	charsPerToken = 4
+	// imageTokens is the cost of a high detail 1024x1024 image.
+	imageTokens = 765
+	// lowDetailImageTokens is the fixed cost of a low detail image.
+	lowDetailImageTokens = 85
+	// mediaTokens is a flat estimate for audio and file parts, whose real
+	// cost depends on their duration or page count.
+	mediaTokens = 1000
+)
+
+// CountTokens returns the number of input tokens the request would use. With
+// APIResponses, the Responses request built exactly as for GenerateContent is
+// counted by /v1/responses/input_tokens, which Azure deployments do not serve
+// (ErrAPIUnsupported). Chat completions have no token counting endpoint, so
+// the count is estimated locally from the request, including the system
+// prompt, tools and response schema: text is counted at about four characters
+// per token, and media parts at a flat rate.
+func (m *Model) CountTokens(ctx context.Context, req *model.LLMRequest) (*genai.CountTokensResponse, error) {
+	if m.api == APIResponses {
+		if m.azure {
+			return nil, ErrAPIUnsupported
+		}
+		return m.countResponsesTokens(ctx, req)
+	}
+
+	params, err := m.buildChatCompletionParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []map[string]any
+	if err := remarshal(params.Messages, &messages); err != nil {
+		return nil, fmt.Errorf("failed to count tokens: %w", err)
+	}
+
+	total := replyPrimingTokens
+	for _, msg := range messages {
+		total += tokensPerMessage + estimateMessageTokens(msg)
+	}
+
+	if len(params.Tools) > 0 {
+		toolsJSON, err := json.Marshal(params.Tools)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		total += estimateTextTokens(string(toolsJSON))
+	}
+	if schema := params.ResponseFormat.OfJSONSchema; schema != nil {
+		schemaJSON, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		total += estimateTextTokens(string(schemaJSON))
+	}
+
+	return &genai.CountTokensResponse{TotalTokens: int32(total)}, nil
+}
+
+// countResponsesTokens counts the input tokens of a Responses API request.
+func (m *Model) countResponsesTokens(ctx context.Context, req *model.LLMRequest) (*genai.CountTokensResponse, error) {
+	params, err := m.buildResponseParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.Responses.InputTokens.Count(ctx, inputTokenCountParams(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tokens: %w", classifyError(err))
+	}
+
+	return &genai.CountTokensResponse{TotalTokens: int32(resp.InputTokens)}, nil
+}
+
+// inputTokenCountParams copies the fields of a Responses request that
+// input_tokens accepts.
+func inputTokenCountParams(params responses.ResponseNewParams) responses.InputTokenCountParams {
+	choice := params.ToolChoice
+	return responses.InputTokenCountParams{
+		Model:              openai.String(string(params.Model)),
+		Instructions:       params.Instructions,
+		Input:              responses.InputTokenCountParamsInputUnion{OfResponseInputItemArray: params.Input.OfInputItemList},
+		Tools:              params.Tools,
+		ParallelToolCalls:  params.ParallelToolCalls,
+		PreviousResponseID: params.PreviousResponseID,
+		Reasoning:          params.Reasoning,
+		Text: responses.InputTokenCountParamsText{
+			Format:    params.Text.Format,
+			Verbosity: string(params.Text.Verbosity),
+		},
+		ToolChoice: responses.InputTokenCountParamsToolChoiceUnion{
+			OfToolChoiceMode:               choice.OfToolChoiceMode,
+			OfAllowedTools:                 choice.OfAllowedTools,
+			OfHostedTool:                   choice.OfHostedTool,
+			OfFunctionTool:                 choice.OfFunctionTool,
+			OfMcpTool:                      choice.OfMcpTool,
+			OfCustomTool:                   choice.OfCustomTool,
+			OfSpecificApplyPatchToolChoice: choice.OfSpecificApplyPatchToolChoice,
+			OfSpecificShellToolChoice:      choice.OfSpecificShellToolChoice,
+		},
+	}
+}
+
+// remarshal converts a value to another type through its JSON encoding.
+func remarshal(from, to any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+// estimateMessageTokens estimates the tokens of an encoded chat message:
+// its text content, media parts, and other fields such as tool calls.
+func estimateMessageTokens(msg map[string]any) int {
+	tokens := 0
+	for key, value := range msg {
+		if key == "content" {
+			tokens += estimateContentTokens(value)
+			continue
+		}
+		tokens += estimateValueTokens(value)
+	}
+	return tokens
+}
+
+// estimateContentTokens estimates the tokens of a message content, which is
+// either a string or a list of content parts.
+func estimateContentTokens(content any) int {
+	parts, ok := content.([]any)
+	if !ok {
+		return estimateValueTokens(content)
+	}
+
+	tokens := 0
+	for _, part := range parts {
+		partMap, ok := part.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch partMap["type"] {
+		case "image_url":
+			if image, _ := partMap["image_url"].(map[string]any); image["detail"] == "low" {
+				tokens += lowDetailImageTokens
+			} else {
+				tokens += imageTokens
+			}
+		case "input_audio", "file":
+			tokens += mediaTokens
+		default:
+			tokens += estimateValueTokens(partMap["text"]) + estimateValueTokens(partMap["refusal"])
+		}
+	}
+	return tokens
+}
+
+// estimateValueTokens estimates the tokens of every string in a decoded JSON value.
+func estimateValueTokens(value any) int {
+	switch v := value.(type) {
+	case string:
+		return estimateTextTokens(v)
+	case []any:
+		tokens := 0
+		for _, item := range v {
+			tokens += estimateValueTokens(item)
+		}
+		return tokens
+	case map[string]any:
+		tokens := 0
+		for _, item := range v {
+			tokens += estimateValueTokens(item)
+		}
+		return tokens
+	}
+	return 0
+}
+
+// estimateTextTokens estimates the tokens of a text: ASCII characters at
+// charsPerToken each, and one token per other character, as scripts such as
+// CJK take about one token per character.
+func estimateTextTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+charsPerToken-1)/charsPerToken + other
+}
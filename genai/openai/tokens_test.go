@@ -0,0 +1,118 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestEstimateTextTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hi", 1},
+		{"Hello, world", 3},
+		{"こんにちは", 5},
+	}
+	for _, tt := range tests {
+		if got := estimateTextTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTextTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	m := New(Config{APIKey: "test", ModelName: "gpt-4o"})
+	count := func(req *model.LLMRequest) int32 {
+		t.Helper()
+		resp, err := m.CountTokens(context.Background(), req)
+		if err != nil {
+			t.Fatalf("CountTokens failed: %v", err)
+		}
+		return resp.TotalTokens
+	}
+
+	// 3 (priming) + 3 (message) + 2 ("user") + 2 ("Hello world!")
+	base := count(userRequest("Hello world!"))
+	if base != 10 {
+		t.Errorf("Expected 10 tokens, got %d", base)
+	}
+
+	withSystem := userRequest("Hello world!")
+	withSystem.Config = &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("You are a helpful assistant.", genai.RoleUser),
+	}
+	if got := count(withSystem); got <= base {
+		t.Errorf("Expected the system prompt to add tokens, got %d", got)
+	}
+
+	withTools := userRequest("Hello world!")
+	withTools.Config = &genai.GenerateContentConfig{Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+		Name:        "get_weather",
+		Description: "Get the weather for a city",
+		Parameters:  &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}}},
+	}}}}}
+	if got := count(withTools); got <= base+10 {
+		t.Errorf("Expected tools to add tokens, got %d", got)
+	}
+
+	withImage := userRequest("Hello world!")
+	withImage.Contents[0].Parts = append(withImage.Contents[0].Parts, &genai.Part{
+		InlineData: &genai.Blob{MIMEType: "image/png", Data: make([]byte, 100000)},
+	})
+	if got := count(withImage); got != base+imageTokens {
+		t.Errorf("Expected image to add %d tokens, got %d", imageTokens, got-base)
+	}
+}
+
+func TestCountTokensResponses(t *testing.T) {
+	var path string
+	var reqBody map[string]any
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"object": "response.input_tokens", "input_tokens": 42})
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "o4-mini", API: APIResponses})
+	req := userRequest("Hello world!")
+	req.Config = &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText("You are a helpful assistant.", genai.RoleUser),
+		Tools:             []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_weather"}}}},
+	}
+	resp, err := m.CountTokens(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+
+	if resp.TotalTokens != 42 {
+		t.Errorf("Expected 42 tokens, got %d", resp.TotalTokens)
+	}
+	if path != "/responses/input_tokens" {
+		t.Errorf("Expected the input_tokens endpoint, got %s", path)
+	}
+	if reqBody["model"] != "o4-mini" || reqBody["instructions"] != "You are a helpful assistant." || len(reqBody["input"].([]any)) != 1 || len(reqBody["tools"].([]any)) != 1 {
+		t.Errorf("Expected the Responses request to be counted, got %v", reqBody)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// OpenAI enforces a 40-character limit on tool_call_id fields.
+const maxToolCallIDLength = 40
+
+// DefaultToolCallIDCacheSize is the number of shortened tool-call IDs
+// remembered when Config.ToolCallIDCacheSize is not set.
+const DefaultToolCallIDCacheSize = 4096
+
+// normalizeToolCallID shortens IDs exceeding OpenAI's 40-char limit using a hash.
+// The mapping is stored to allow reverse lookup if needed.
+func (m *Model) normalizeToolCallID(id string) string {
+	if len(id) <= maxToolCallIDLength {
+		return id
+	}
+
+	hash := sha256.Sum256([]byte(id))
+	shortID := "tc_" + hex.EncodeToString(hash[:])[:maxToolCallIDLength-3]
+
+	m.toolCallIDs.put(shortID, id)
+
+	return shortID
+}
+
+// denormalizeToolCallID restores the original ID from a shortened one.
+func (m *Model) denormalizeToolCallID(shortID string) string {
+	if original, exists := m.toolCallIDs.get(shortID); exists {
+		return original
+	}
+	return shortID
+}
+
+// resolveToolCallID returns the ID to expose for a tool call returned by the
+// provider: the original ID if it was shortened by normalizeToolCallID, or a
+// deterministic ID when the provider sent none (e.g. some Ollama models).
+func (m *Model) resolveToolCallID(id, responseID string, index int64, name string) string {
+	if id == "" {
+		return generateToolCallID(responseID, index, name)
+	}
+	return m.denormalizeToolCallID(id)
+}
+
+// generateToolCallID derives a tool-call ID from the response ID, the call's
+// index and the tool name, so streamed and final responses agree on it.
+func generateToolCallID(responseID string, index int64, name string) string {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d\x00%s", responseID, index, name))
+	return "call_" + hex.EncodeToString(hash[:12])
+}
+
+// toolCallIDCache is a fixed-size LRU map from shortened to original tool-call IDs.
+type toolCallIDCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// toolCallIDEntry is the value stored in toolCallIDCache list elements.
+type toolCallIDEntry struct {
+	shortID  string
+	original string
+}
+
+// newToolCallIDCache creates a cache holding up to size entries.
+// A size of zero or less uses DefaultToolCallIDCacheSize.
+func newToolCallIDCache(size int) *toolCallIDCache {
+	if size <= 0 {
+		size = DefaultToolCallIDCacheSize
+	}
+	return &toolCallIDCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// put stores a mapping, evicting the least recently used one when full.
+func (c *toolCallIDCache) put(shortID, original string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[shortID]; exists {
+		elem.Value.(*toolCallIDEntry).original = original
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[shortID] = c.lru.PushFront(&toolCallIDEntry{shortID: shortID, original: original})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*toolCallIDEntry).shortID)
+	}
+}
+
+// get returns the original ID for a shortened one and marks it as recently used.
+func (c *toolCallIDCache) get(shortID string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.entries[shortID]
+	if !exists {
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*toolCallIDEntry).original, true
+}
+
+// len returns the number of stored mappings.
+func (c *toolCallIDCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
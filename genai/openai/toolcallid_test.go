@@ -0,0 +1,119 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/achetronic/adk-utils-go/genai/toolcall"
+	"google.golang.org/genai"
+)
+
+func TestToolCallIDCacheEviction(t *testing.T) {
+	cache := newToolCallIDCache(2)
+	cache.put("a", "original-a")
+	cache.put("b", "original-b")
+
+	// Touching "a" makes "b" the least recently used entry
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	cache.put("c", "original-c")
+
+	if cache.len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.len())
+	}
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if original, ok := cache.get("a"); !ok || original != "original-a" {
+		t.Errorf("Expected a to survive, got %q", original)
+	}
+}
+
+func TestToolCallIDRoundTrip(t *testing.T) {
+	longID := "adk-" + strings.Repeat("x", 60)
+
+	var reqBody map[string]any
+	var shortID string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reqBody = nil
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		messages := reqBody["messages"].([]any)
+		shortID, _ = messages[2].(map[string]any)["tool_call_id"].(string)
+
+		// The provider echoes the shortened ID back
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":     "chatcmpl-1",
+			"object": "chat.completion",
+			"model":  "test-model",
+			"choices": []any{map[string]any{"index": 0, "finish_reason": "tool_calls", "message": map[string]any{
+				"role": "assistant",
+				"tool_calls": []any{map[string]any{
+					"id": shortID, "type": "function",
+					"function": map[string]any{"name": "get_weather", "arguments": "{}"},
+				}},
+			}}},
+		})
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model"})
+	req := userRequest("weather?")
+	req.Contents = append(req.Contents,
+		&genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{ID: longID, Name: "get_weather"}}}},
+		&genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: longID, Name: "get_weather", Response: map[string]any{"temp": 20}}}}},
+	)
+	responses := collect(t, m, req, false)
+
+	if len(shortID) > maxToolCallIDLength {
+		t.Fatalf("Expected shortened ID, got %q", shortID)
+	}
+	if id := responses[0].Content.Parts[0].FunctionCall.ID; id != longID {
+		t.Errorf("Expected original ID %q to be restored, got %q", longID, id)
+	}
+}
+
+func TestGeneratedToolCallIDs(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(t, w,
+			streamChunk(map[string]any{"role": "assistant", "tool_calls": []any{
+				map[string]any{"index": 0, "id": "", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`}},
+			}}, ""),
+			streamChunk(map[string]any{}, "tool_calls"),
+		)
+	})
+
+	m := New(Config{BaseURL: server.URL, APIKey: "test", ModelName: "test-model", StreamToolCalls: true})
+	responses := collect(t, m, userRequest("weather?"), true)
+
+	want := generateToolCallID("chatcmpl-test", 0, "get_weather")
+	if !strings.HasPrefix(want, "call_") || len(want) > maxToolCallIDLength {
+		t.Fatalf("Unexpected generated ID %q", want)
+	}
+	for i, resp := range responses[:len(responses)-1] {
+		if delta := resp.CustomMetadata[toolcall.DeltaKey].(toolcall.Delta); delta.ID != want {
+			t.Errorf("Response %d: expected ID %q, got %+v", i, want, delta)
+		}
+	}
+	if fc := responses[len(responses)-1].Content.Parts[0].FunctionCall; fc == nil || fc.ID != want {
+		t.Errorf("Final response: expected ID %q, got %+v", want, fc)
+	}
+}
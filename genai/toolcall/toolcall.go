@@ -0,0 +1,42 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolcall defines how the genai/anthropic and genai/openai clients
+// report the progress of streamed tool calls, so callers can follow them
+// without depending on either client.
+package toolcall
+
+// DeltaKey is the CustomMetadata key holding the Delta of a partial response
+// that reports tool-call progress.
+const DeltaKey = "tool_call_delta"
+
+// Delta reports the progress of a streamed tool call. Partial responses carry
+// it in CustomMetadata rather than as a FunctionCall part, which ADK flows
+// would execute; the complete calls arrive as FunctionCall parts in the final
+// response.
+type Delta struct {
+	// Index is the tool-call index within the response: the tool-call index
+	// for OpenAI, the content block index of the tool_use block for Anthropic.
+	Index int
+	// ID is the tool-call ID, empty until known.
+	ID string
+	// Name is the tool name, empty until known.
+	Name string
+	// ArgumentsDelta is the JSON arguments fragment received, if any.
+	ArgumentsDelta string
+	// Done reports that the call's arguments are complete.
+	Done bool
+	// Args holds the parsed arguments once Done is set.
+	Args map[string]any
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 achetronic
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package azure holds the Azure OpenAI deployment configuration shared by
+// the genai/openai client and the memory/postgres embedding client.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultAPIVersion is the Azure OpenAI api-version used when
+// Config.APIVersion is empty.
+const DefaultAPIVersion = "2024-10-21"
+
+// Config configures access to an Azure OpenAI deployment.
+type Config struct {
+	// Endpoint is the resource endpoint (e.g., "https://my-resource.openai.azure.com").
+	Endpoint string
+	// Deployment is the name of the model deployment. Requests are sent to
+	// {Endpoint}/openai/deployments/{Deployment}.
+	Deployment string
+	// APIVersion is sent as the api-version query parameter.
+	// Defaults to DefaultAPIVersion.
+	APIVersion string
+	// APIKey is sent in the api-key header. Falls back to AZURE_OPENAI_API_KEY
+	// env var if empty and no TokenProvider is set.
+	APIKey string
+	// TokenProvider returns Microsoft Entra ID access tokens, sent as Bearer
+	// tokens instead of the API key. It is called for every request, so it
+	// should cache tokens (azidentity credentials do), e.g.:
+	//
+	//	func(ctx context.Context) (string, error) {
+	//	    token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+	//	        Scopes: []string{"https://cognitiveservices.azure.com/.default"},
+	//	    })
+	//	    return token.Token, err
+	//	}
+	TokenProvider func(ctx context.Context) (string, error)
+}
+
+// BaseURL returns the deployment URL that API paths are appended to.
+func (c Config) BaseURL() string {
+	return fmt.Sprintf("%s/openai/deployments/%s", strings.TrimSuffix(c.Endpoint, "/"), url.PathEscape(c.Deployment))
+}
+
+// URL returns the full URL of an API path (e.g., "/embeddings"), including
+// the api-version query parameter.
+func (c Config) URL(path string) string {
+	return c.BaseURL() + "/" + strings.TrimPrefix(path, "/") + "?api-version=" + url.QueryEscape(c.Version())
+}
+
+// Authorize sets the authentication header on a request: a Bearer token
+// from TokenProvider if set, or the api-key header otherwise.
+func (c Config) Authorize(req *http.Request) error {
+	if c.TokenProvider != nil {
+		token, err := c.TokenProvider(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get Azure access token: %w", err)
+		}
+		req.Header.Del("Api-Key")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	apiKey := c.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	}
+	if apiKey != "" {
+		req.Header.Del("Authorization")
+		req.Header.Set("Api-Key", apiKey)
+	}
+	return nil
+}
+
+// Version returns the configured API version or the default one.
+func (c Config) Version() string {
+	if c.APIVersion != "" {
+		return c.APIVersion
+	}
+	return DefaultAPIVersion
+}
@@ -22,8 +22,14 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/achetronic/adk-utils-go/internal/azure"
 )
 
+// AzureConfig configures access to an Azure OpenAI deployment. It is the same
+// type as genai/openai.AzureConfig, so one value can configure both.
+type AzureConfig = azure.Config
+
 // OpenAICompatibleEmbedding implements EmbeddingModel using the OpenAI embeddings API format.
 // This is the de facto standard supported by: OpenAI, Ollama (/v1), Azure OpenAI, vLLM, LocalAI, LiteLLM, etc.
 type OpenAICompatibleEmbedding struct {
@@ -32,6 +38,9 @@ type OpenAICompatibleEmbedding struct {
 	Model   string // e.g., "text-embedding-3-small", "nomic-embed-text"
 	dim     int    // embedding dimension, auto-detected if 0
 
+	// Azure targets an Azure OpenAI deployment instead of BaseURL/APIKey.
+	Azure *AzureConfig
+
 	// HTTPClient allows customizing the HTTP client used for requests.
 	// If nil, http.DefaultClient is used.
 	HTTPClient *http.Client
@@ -44,6 +53,11 @@ type OpenAICompatibleEmbeddingConfig struct {
 	Model     string
 	Dimension int // optional, will be auto-detected on first call if 0
 
+	// Azure targets an Azure OpenAI deployment (the same configuration as
+	// genai/openai.Config.Azure). BaseURL and APIKey are ignored when set,
+	// and Model defaults to the deployment.
+	Azure *AzureConfig
+
 	// HTTPClient allows customizing the HTTP client used for requests.
 	// Useful for testing with mock servers.
 	HTTPClient *http.Client
@@ -56,11 +70,16 @@ func NewOpenAICompatibleEmbedding(cfg OpenAICompatibleEmbeddingConfig) *OpenAICo
 	if httpClient == nil {
 		httpClient = http.DefaultClient
 	}
+	modelName := cfg.Model
+	if modelName == "" && cfg.Azure != nil {
+		modelName = cfg.Azure.Deployment
+	}
 	return &OpenAICompatibleEmbedding{
 		BaseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
 		APIKey:     cfg.APIKey,
-		Model:      cfg.Model,
+		Model:      modelName,
 		dim:        cfg.Dimension,
+		Azure:      cfg.Azure,
 		HTTPClient: httpClient,
 	}
 }
@@ -82,12 +101,21 @@ func (e *OpenAICompatibleEmbedding) Embed(ctx context.Context, text string) ([]f
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", e.BaseURL+"/embeddings", bytes.NewReader(jsonBody))
+	url := e.BaseURL + "/embeddings"
+	if e.Azure != nil {
+		url = e.Azure.URL("/embeddings")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if e.APIKey != "" {
+	if e.Azure != nil {
+		if err := e.Azure.Authorize(req); err != nil {
+			return nil, err
+		}
+	} else if e.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+e.APIKey)
 	}
 
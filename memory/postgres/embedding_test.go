@@ -334,3 +334,51 @@ func TestEmbedBaseURLTrailingSlash(t *testing.T) {
 
 	t.Logf("✓ Embed trailing slash: correctly handled")
 }
+
+func TestEmbedAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verify deployment URL, api-version and api-key header
+		if r.URL.Path != "/openai/deployments/my-embeddings/embeddings" {
+			t.Errorf("Expected deployment path, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") != "2024-06-01" {
+			t.Errorf("Expected api-version 2024-06-01, got %q", r.URL.Query().Get("api-version"))
+		}
+		if r.Header.Get("Api-Key") != "azure-key" || r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected api-key auth only, got api-key=%q authorization=%q", r.Header.Get("Api-Key"), r.Header.Get("Authorization"))
+		}
+
+		var reqBody map[string]any
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody["model"] != "my-embeddings" {
+			t.Errorf("Expected model to default to the deployment, got %v", reqBody["model"])
+		}
+
+		resp := map[string]any{
+			"data": []map[string]any{
+				{"embedding": []float32{0.1}, "index": 0},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	emb := NewOpenAICompatibleEmbedding(OpenAICompatibleEmbeddingConfig{
+		BaseURL: "http://ignored",
+		APIKey:  "ignored",
+		Azure: &AzureConfig{
+			Endpoint:   server.URL + "/",
+			Deployment: "my-embeddings",
+			APIVersion: "2024-06-01",
+			APIKey:     "azure-key",
+		},
+		HTTPClient: server.Client(),
+	})
+
+	_, err := emb.Embed(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	t.Logf("✓ Embed with Azure: deployment URL and api-key header correctly set")
+}